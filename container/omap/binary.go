@@ -0,0 +1,691 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// GobEncode implements [gob.GobEncoder].
+//
+// As usual with gob, the dynamic types of interface keys and values
+// must be registered with [gob.Register].
+// A nil map is encoded as no bytes.
+func (m Map[K, V]) GobEncode() ([]byte, error) {
+
+	if m.IsNil() {
+		return nil, nil
+	}
+
+	b := new(bytes.Buffer)
+	enc := gob.NewEncoder(b)
+
+	if err := enc.Encode(m.Len()); err != nil {
+		return nil, err
+	}
+
+	for _, t := range m.s {
+		// Encoding through pointers lets gob
+		// handle interface keys and values.
+		if err := enc.Encode(&t.key); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(&t.val); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// GobDecode implements [gob.GobDecoder].
+// Decoding no bytes, the encoding of a nil map, leaves m unchanged.
+func (m *Map[K, V]) GobDecode(b []byte) error {
+
+	if len(b) == 0 {
+		return nil
+	}
+
+	m.init()
+	dec := gob.NewDecoder(bytes.NewReader(b))
+
+	var n int
+	if err := dec.Decode(&n); err != nil {
+		return err
+	}
+
+	for range n {
+		var key K
+		var val V
+		if err := dec.Decode(&key); err != nil {
+			return err
+		}
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		m.Set(key, val)
+	}
+
+	return nil
+}
+
+// EncodeMsgpack writes the map to w as a MessagePack map,
+// keeping the order of the keys.
+//
+// Keys and values are encoded by reflection:
+// Go maps and structs (by field name) become MessagePack maps,
+// slices and arrays become arrays, and types implementing
+// [encoding.BinaryMarshaler] become binary strings.
+//
+// Many small writes are made to w,
+// so consider wrapping it in a [bufio.Writer].
+func (m Map[K, V]) EncodeMsgpack(w io.Writer) error {
+	return m.encodeBin(&msgpackEncoder{binWriter{w: w}})
+}
+
+// DecodeMsgpack reads a MessagePack map from r into m,
+// without reading past the end of the map.
+//
+// When V is any, maps are decoded into Map[string, any]
+// if all their keys are strings, and into Map[any, any] otherwise.
+//
+// Unless r implements [io.ByteReader], it's read one byte at a time,
+// so consider wrapping it in a [bufio.Reader].
+func (m *Map[K, V]) DecodeMsgpack(r io.Reader) error {
+	x, err := (&msgpackDecoder{r: newBinReader(r)}).decode()
+	if err != nil {
+		return err
+	}
+	return m.assignBin(x)
+}
+
+// EncodeCBOR writes the map to w as a CBOR map,
+// keeping the order of the keys.
+//
+// See [Map.EncodeMsgpack] for how keys and values are encoded.
+func (m Map[K, V]) EncodeCBOR(w io.Writer) error {
+	return m.encodeBin(&cborEncoder{binWriter{w: w}})
+}
+
+// DecodeCBOR reads a CBOR map from r into m,
+// without reading past the end of the map.
+// Tags are ignored, and their content is decoded as is.
+//
+// See [Map.DecodeMsgpack] for how values are decoded.
+func (m *Map[K, V]) DecodeCBOR(r io.Reader) error {
+	x, err := (&cborDecoder{r: newBinReader(r)}).decode()
+	if err != nil {
+		return err
+	}
+	return m.assignBin(x)
+}
+
+func (m Map[K, V]) encodeBin(e binEncoder) error {
+
+	if m.IsNil() {
+		return e.encodeNil()
+	}
+
+	if err := e.encodeMapHeader(len(m.s)); err != nil {
+		return err
+	}
+
+	for i := range m.s {
+		if err := encodeBin(e, reflect.ValueOf(&m.s[i].key).Elem()); err != nil {
+			return err
+		}
+		if err := encodeBin(e, reflect.ValueOf(&m.s[i].val).Elem()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignBin sets the key-value pairs of a decoded map in m.
+// A decoded nil leaves m unchanged.
+func (m *Map[K, V]) assignBin(x any) error {
+
+	if x == nil {
+		return nil
+	}
+
+	bm, ok := x.(binMap)
+	if !ok {
+		return fmt.Errorf("cannot decode %s into %T", binTypeName(x), *m)
+	}
+
+	m.init()
+
+	for _, p := range bm {
+		var key K
+		var val V
+		if err := assignBin(reflect.ValueOf(&key).Elem(), p.key); err != nil {
+			return err
+		}
+		if err := assignBin(reflect.ValueOf(&val).Elem(), p.val); err != nil {
+			return err
+		}
+		m.Set(key, val)
+	}
+
+	return nil
+}
+
+// binEncoder writes the primitives of a binary format.
+type binEncoder interface {
+	encodeNil() error
+	encodeBool(bool) error
+	encodeInt(int64) error
+	encodeUint(uint64) error
+	encodeFloat32(float32) error
+	encodeFloat64(float64) error
+	encodeString(string) error
+	encodeBytes([]byte) error
+	encodeArrayHeader(n int) error
+	encodeMapHeader(n int) error
+}
+
+// binMarshaler is implemented by the types of this package
+// that encode themselves into binary formats.
+type binMarshaler interface {
+	encodeBin(binEncoder) error
+}
+
+// binUnmarshaler is implemented by the types of this package
+// that decode themselves from binary formats.
+type binUnmarshaler interface {
+	assignBin(any) error
+}
+
+// binMap is a decoded map, with its pairs in order.
+type binMap []binPair
+
+type binPair struct {
+	key any
+	val any
+}
+
+func encodeBin(e binEncoder, v reflect.Value) error {
+
+	if !v.IsValid() {
+		return e.encodeNil()
+	}
+
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case binMarshaler:
+			return x.encodeBin(e)
+		case encoding.BinaryMarshaler:
+			if v.Kind() == reflect.Pointer && v.IsNil() {
+				return e.encodeNil()
+			}
+			b, err := x.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			return e.encodeBytes(b)
+		}
+	}
+
+	switch v.Kind() {
+
+	case reflect.Bool:
+		return e.encodeBool(v.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.encodeInt(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.encodeUint(v.Uint())
+
+	case reflect.Float32:
+		return e.encodeFloat32(float32(v.Float()))
+
+	case reflect.Float64:
+		return e.encodeFloat64(v.Float())
+
+	case reflect.String:
+		return e.encodeString(v.String())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.encodeNil()
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.encodeBytes(v.Bytes())
+		}
+		fallthrough
+
+	case reflect.Array:
+		if err := e.encodeArrayHeader(v.Len()); err != nil {
+			return err
+		}
+		for i := range v.Len() {
+			if err := encodeBin(e, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return e.encodeNil()
+		}
+		if err := e.encodeMapHeader(v.Len()); err != nil {
+			return err
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := encodeBin(e, iter.Key()); err != nil {
+				return err
+			}
+			if err := encodeBin(e, iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		fields := exportedFields(v.Type())
+		if err := e.encodeMapHeader(len(fields)); err != nil {
+			return err
+		}
+		for _, f := range fields {
+			if err := e.encodeString(f.Name); err != nil {
+				return err
+			}
+			if err := encodeBin(e, v.FieldByIndex(f.Index)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return e.encodeNil()
+		}
+		return encodeBin(e, v.Elem())
+
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+}
+
+func exportedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := range t.NumField() {
+		if f := t.Field(i); f.IsExported() {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// assignBin sets v to the decoded value x.
+func assignBin(v reflect.Value, x any) error {
+
+	if v.CanAddr() {
+		switch u := v.Addr().Interface().(type) {
+		case binUnmarshaler:
+			return u.assignBin(x)
+		case encoding.BinaryUnmarshaler:
+			if b, ok := x.([]byte); ok {
+				return u.UnmarshalBinary(b)
+			}
+		}
+	}
+
+	if x == nil {
+		v.SetZero()
+		return nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("cannot decode %s into %v", binTypeName(x), v.Type())
+	}
+
+	switch v.Kind() {
+
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return mismatch()
+		}
+		n, err := naturalBin(x)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&n).Elem())
+
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := assignBin(p.Elem(), x); err != nil {
+			return err
+		}
+		v.Set(p)
+
+	case reflect.Bool:
+		b, ok := x.(bool)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch x := x.(type) {
+		case int64:
+			i = x
+		case uint64:
+			if x > math.MaxInt64 {
+				return mismatch()
+			}
+			i = int64(x)
+		default:
+			return mismatch()
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("%d overflows %v", i, v.Type())
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		switch x := x.(type) {
+		case uint64:
+			u = x
+		case int64:
+			if x < 0 {
+				return mismatch()
+			}
+			u = uint64(x)
+		default:
+			return mismatch()
+		}
+		if v.OverflowUint(u) {
+			return fmt.Errorf("%d overflows %v", u, v.Type())
+		}
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		switch x := x.(type) {
+		case float64:
+			v.SetFloat(x)
+		case int64:
+			v.SetFloat(float64(x))
+		case uint64:
+			v.SetFloat(float64(x))
+		default:
+			return mismatch()
+		}
+
+	case reflect.String:
+		switch x := x.(type) {
+		case string:
+			v.SetString(x)
+		case []byte:
+			v.SetString(string(x))
+		default:
+			return mismatch()
+		}
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			switch x := x.(type) {
+			case []byte:
+				v.SetBytes(x)
+				return nil
+			case string:
+				v.SetBytes([]byte(x))
+				return nil
+			}
+		}
+		a, ok := x.([]any)
+		if !ok {
+			return mismatch()
+		}
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+		for i, elem := range a {
+			if err := assignBin(s.Index(i), elem); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+	case reflect.Array:
+		a, ok := x.([]any)
+		if !ok || len(a) != v.Len() {
+			return mismatch()
+		}
+		for i, elem := range a {
+			if err := assignBin(v.Index(i), elem); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		bm, ok := x.(binMap)
+		if !ok {
+			return mismatch()
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(bm))
+		for _, p := range bm {
+			key := reflect.New(v.Type().Key()).Elem()
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := assignBin(key, p.key); err != nil {
+				return err
+			}
+			if err := assignBin(val, p.val); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+
+	case reflect.Struct:
+		bm, ok := x.(binMap)
+		if !ok {
+			return mismatch()
+		}
+		fields := exportedFields(v.Type())
+		for _, p := range bm {
+			name, ok := p.key.(string)
+			if !ok {
+				return fmt.Errorf("cannot decode %s field name into %v", binTypeName(p.key), v.Type())
+			}
+			for _, f := range fields {
+				if strings.EqualFold(f.Name, name) {
+					if err := assignBin(v.FieldByIndex(f.Index), p.val); err != nil {
+						return fmt.Errorf("field %s: %w", f.Name, err)
+					}
+					break
+				}
+			}
+		}
+
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
+
+// naturalBin converts the decoded value x into the value
+// it's decoded as when the destination is of type any.
+func naturalBin(x any) (any, error) {
+
+	switch x := x.(type) {
+
+	case []any:
+		a := make([]any, len(x))
+		for i, elem := range x {
+			var err error
+			if a[i], err = naturalBin(elem); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+
+	case binMap:
+		strKeys := true
+		for _, p := range x {
+			if _, ok := p.key.(string); !ok {
+				strKeys = false
+				break
+			}
+		}
+		if strKeys {
+			m := New[string, any](len(x))
+			return m, m.assignBin(x)
+		}
+		m := New[any, any](len(x))
+		for _, p := range x {
+			key, err := naturalBin(p.key)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.TypeOf(key).Comparable() {
+				return nil, fmt.Errorf("cannot use %s as map key", binTypeName(p.key))
+			}
+			val, err := naturalBin(p.val)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(key, val)
+		}
+		return m, nil
+
+	default:
+		return x, nil
+	}
+}
+
+func binTypeName(x any) string {
+	switch x.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case int64, uint64:
+		return "integer"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case []byte:
+		return "binary"
+	case []any:
+		return "array"
+	case binMap:
+		return "map"
+	default:
+		return fmt.Sprintf("%T", x)
+	}
+}
+
+// binMaxDepth is the maximum nesting depth of decoded arrays and maps,
+// so that untrusted input can't exhaust the stack.
+const binMaxDepth = 10000
+
+// binReader reads the input of binary decoders
+// without reading past the decoded value.
+type binReader struct {
+	r   io.Reader
+	br  io.ByteReader
+	buf [8]byte
+}
+
+func newBinReader(r io.Reader) *binReader {
+	br, _ := r.(io.ByteReader)
+	return &binReader{r: r, br: br}
+}
+
+func (r *binReader) readByte() (byte, error) {
+	if r.br != nil {
+		return r.br.ReadByte()
+	}
+	_, err := io.ReadFull(r.r, r.buf[:1])
+	return r.buf[0], err
+}
+
+// readUint reads a big-endian unsigned integer of n bytes.
+func (r *binReader) readUint(n int) (uint64, error) {
+	var x uint64
+	for range n {
+		c, err := r.readByte()
+		if err != nil {
+			return 0, noEOF(err)
+		}
+		x = x<<8 | uint64(c)
+	}
+	return x, nil
+}
+
+// readBytes reads n bytes, allocating gradually
+// so that a bogus length can't exhaust memory.
+func (r *binReader) readBytes(n uint64) ([]byte, error) {
+	const chunk = 64 * 1024
+	var b []byte
+	for uint64(len(b)) < n {
+		m := min(n-uint64(len(b)), chunk)
+		b = append(b, make([]byte, m)...)
+		s := b[len(b)-int(m):]
+		if r.br != nil {
+			for i := range s {
+				c, err := r.br.ReadByte()
+				if err != nil {
+					return nil, noEOF(err)
+				}
+				s[i] = c
+			}
+		} else if _, err := io.ReadFull(r.r, s); err != nil {
+			return nil, noEOF(err)
+		}
+	}
+	if b == nil {
+		b = []byte{}
+	}
+	return b, nil
+}
+
+// noEOF turns io.EOF in the middle of a value into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// binWriter writes the output of binary encoders.
+type binWriter struct {
+	w   io.Writer
+	buf [9]byte
+}
+
+// writeHeader writes b followed by the n low bytes of x in big-endian.
+func (w *binWriter) writeHeader(b byte, x uint64, n int) error {
+	w.buf[0] = b
+	for i := range n {
+		w.buf[n-i] = byte(x >> (8 * i))
+	}
+	_, err := w.w.Write(w.buf[:n+1])
+	return err
+}
+
+func (w *binWriter) write(b []byte) error {
+	_, err := w.w.Write(b)
+	return err
+}
+
+func (w *binWriter) writeString(s string) error {
+	_, err := io.WriteString(w.w, s)
+	return err
+}
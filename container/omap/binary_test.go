@@ -0,0 +1,246 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+type binFormat struct {
+	name   string
+	encode func(m omap.Map[string, any], w io.Writer) error
+	decode func(m *omap.Map[string, any], r io.Reader) error
+}
+
+var binFormats = []binFormat{
+	{
+		"msgpack",
+		omap.Map[string, any].EncodeMsgpack,
+		(*omap.Map[string, any]).DecodeMsgpack,
+	},
+	{
+		"cbor",
+		omap.Map[string, any].EncodeCBOR,
+		(*omap.Map[string, any]).DecodeCBOR,
+	},
+}
+
+func testDoc() omap.Map[string, any] {
+	inner := omap.New[string, any]()
+	inner.Set("z", int64(-300))
+	inner.Set("a", 1.5)
+	m := omap.New[string, any]()
+	m.Set("s", "text")
+	m.Set("n", uint64(70000))
+	m.Set("b", true)
+	m.Set("nil", nil)
+	m.Set("bin", []byte{1, 2})
+	m.Set("arr", []any{"x", false})
+	m.Set("obj", inner)
+	return m
+}
+
+// mapCmp compares maps by their entries, in order.
+var mapCmp = cmp.Transformer("entries", func(m omap.Map[string, any]) []any {
+	var s []any
+	for k, v := range m.All() {
+		s = append(s, k, v)
+	}
+	return s
+})
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, f := range binFormats {
+		t.Run(f.name, func(t *testing.T) {
+
+			want := testDoc()
+			var b bytes.Buffer
+			if err := f.encode(want, &b); err != nil {
+				t.Fatal(err)
+			}
+			b.WriteString("trailing")
+
+			var got omap.Map[string, any]
+			if err := f.decode(&got, &b); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got, mapCmp); diff != "" {
+				t.Errorf("incorrect result (-want +got):\n%s", diff)
+			}
+			if b.String() != "trailing" {
+				t.Errorf("decoding read past the end of the map")
+			}
+		})
+	}
+}
+
+func TestBinaryVectors(t *testing.T) {
+
+	m := omap.New[string, any]()
+	m.Set("b", int64(1))
+	m.Set("a", []any{true, nil, int64(-1)})
+
+	tests := []struct {
+		format binFormat
+		want   string
+	}{
+		{binFormats[0], "\x82\xa1b\x01\xa1a\x93\xc3\xc0\xff"},
+		{binFormats[1], "\xa2\x61b\x01\x61a\x83\xf5\xf6\x20"},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := tt.format.encode(m, &b); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+			t.Errorf("%s: incorrect encoding (-want +got):\n%s", tt.format.name, diff)
+		}
+	}
+}
+
+func TestCBORDecodeVectors(t *testing.T) {
+
+	// Examples from RFC 8949, Appendix A.
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"\xf9\x3c\x00", 1.0},
+		{"\xf9\xc4\x00", -4.0},
+		{"\x3b\x00\x00\x00\x00\x00\x00\x00\x00", int64(-1)},
+		{"\xc1\x1a\x51\x4b\x67\xb0", uint64(1363896240)},
+		{"\x7f\x65strea\x64ming\xff", "streaming"},
+		{"\x9f\x01\x82\x02\x03\xff", []any{uint64(1), []any{uint64(2), uint64(3)}}},
+	}
+
+	for _, tt := range tests {
+		var m omap.Map[string, any]
+		in := "\xa1\x61k" + tt.in
+		if err := m.DecodeCBOR(strings.NewReader(in)); err != nil {
+			t.Fatalf("%x: %v", tt.in, err)
+		}
+		got, _ := m.Get("k")
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%x: incorrect result (-want +got):\n%s", tt.in, diff)
+		}
+	}
+
+	var m omap.Map[string, any]
+	in := "\xbf\x61a\x01\x61b\x9f\x02\x03\xff\xff"
+	if err := m.DecodeCBOR(strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.String(); got != "omap[a:1 b:[2 3]]" {
+		t.Errorf("indefinite-length map: got %s", got)
+	}
+}
+
+func TestBinaryMalformed(t *testing.T) {
+
+	for _, f := range binFormats {
+		t.Run(f.name, func(t *testing.T) {
+
+			var b bytes.Buffer
+			f.encode(testDoc(), &b)
+			enc := b.Bytes()
+
+			for n := 1; n < len(enc); n++ {
+				var m omap.Map[string, any]
+				err := f.decode(&m, bytes.NewReader(enc[:n]))
+				if !errors.Is(err, io.ErrUnexpectedEOF) {
+					t.Fatalf("decoding %d of %d bytes: got %v, want io.ErrUnexpectedEOF", n, len(enc), err)
+				}
+			}
+
+			var m omap.Map[string, any]
+			if err := f.decode(&m, bytes.NewReader(nil)); err != io.EOF {
+				t.Errorf("decoding no bytes: got %v, want io.EOF", err)
+			}
+		})
+	}
+
+	malformed := []struct {
+		format binFormat
+		in     string
+	}{
+		{binFormats[0], "\x81\xc1"},         // reserved format
+		{binFormats[0], "\x01"},             // not a map
+		{binFormats[1], "\xa1\x1c"},         // reserved additional information
+		{binFormats[1], "\xa1\xff"},         // unexpected break
+		{binFormats[1], "\xbf\x01\xff"},     // odd indefinite-length map
+		{binFormats[1], "\xa1\x7f\x01\xff"}, // integer chunk in a string
+	}
+
+	for _, tt := range malformed {
+		var m omap.Map[string, any]
+		if err := tt.format.decode(&m, strings.NewReader(tt.in)); err == nil {
+			t.Errorf("%s: decoding %x succeeded", tt.format.name, tt.in)
+		}
+	}
+}
+
+func TestBinaryDepth(t *testing.T) {
+
+	// A map with a single key whose value is
+	// a million nested single-element arrays.
+	tests := []struct {
+		format binFormat
+		prefix string
+		nest   string
+	}{
+		{binFormats[0], "\x81\xa1k", "\x91"},
+		{binFormats[1], "\xa1\x61k", "\x81"},
+	}
+
+	for _, tt := range tests {
+		s := tt.prefix + strings.Repeat(tt.nest, 1e6)
+		var m omap.Map[string, any]
+		err := tt.format.decode(&m, strings.NewReader(s))
+		if err == nil || !strings.Contains(err.Error(), "depth") {
+			t.Errorf("%s: decoding deeply nested arrays: got %v, want depth error", tt.format.name, err)
+		}
+	}
+}
+
+func TestGob(t *testing.T) {
+
+	type doc struct {
+		M omap.Map[string, int]
+		N omap.Map[string, int]
+	}
+
+	in := doc{M: omap.New[string, int]()}
+	in.M.Set("b", 2)
+	in.M.Set("a", 1)
+
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+
+	var out doc
+	if err := gob.NewDecoder(&b).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.M.String(); got != "omap[b:2 a:1]" {
+		t.Errorf("decoded map: got %s", got)
+	}
+	if !out.N.IsNil() {
+		t.Errorf("decoded nil map isn't nil: %s", out.N)
+	}
+
+	var m omap.Map[string, int]
+	if err := m.GobDecode([]byte{0x03}); err == nil {
+		t.Error("decoding malformed gob succeeded")
+	}
+}
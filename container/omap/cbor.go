@@ -0,0 +1,295 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CBOR major types.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+const cborIndefinite = 31
+
+type cborEncoder struct {
+	binWriter
+}
+
+// head writes the initial bytes of a data item
+// with the given major type and argument.
+func (e *cborEncoder) head(major byte, x uint64) error {
+	switch {
+	case x < 24:
+		return e.writeHeader(major|byte(x), 0, 0)
+	case x <= math.MaxUint8:
+		return e.writeHeader(major|24, x, 1)
+	case x <= math.MaxUint16:
+		return e.writeHeader(major|25, x, 2)
+	case x <= math.MaxUint32:
+		return e.writeHeader(major|26, x, 4)
+	default:
+		return e.writeHeader(major|27, x, 8)
+	}
+}
+
+func (e *cborEncoder) encodeNil() error {
+	return e.writeHeader(0xf6, 0, 0)
+}
+
+func (e *cborEncoder) encodeBool(b bool) error {
+	if b {
+		return e.writeHeader(0xf5, 0, 0)
+	}
+	return e.writeHeader(0xf4, 0, 0)
+}
+
+func (e *cborEncoder) encodeInt(i int64) error {
+	if i >= 0 {
+		return e.head(cborUint, uint64(i))
+	}
+	return e.head(cborNegInt, uint64(-1-i))
+}
+
+func (e *cborEncoder) encodeUint(u uint64) error {
+	return e.head(cborUint, u)
+}
+
+func (e *cborEncoder) encodeFloat32(f float32) error {
+	return e.writeHeader(0xfa, uint64(math.Float32bits(f)), 4)
+}
+
+func (e *cborEncoder) encodeFloat64(f float64) error {
+	return e.writeHeader(0xfb, math.Float64bits(f), 8)
+}
+
+func (e *cborEncoder) encodeString(s string) error {
+	if err := e.head(cborText, uint64(len(s))); err != nil {
+		return err
+	}
+	return e.writeString(s)
+}
+
+func (e *cborEncoder) encodeBytes(b []byte) error {
+	if err := e.head(cborBytes, uint64(len(b))); err != nil {
+		return err
+	}
+	return e.write(b)
+}
+
+func (e *cborEncoder) encodeArrayHeader(n int) error {
+	return e.head(cborArray, uint64(n))
+}
+
+func (e *cborEncoder) encodeMapHeader(n int) error {
+	return e.head(cborMap, uint64(n))
+}
+
+type cborDecoder struct {
+	r     *binReader
+	depth int
+}
+
+// errCBORBreak is returned by decode
+// upon the "break" stop code of indefinite-length items.
+var errCBORBreak = errors.New("cbor: unexpected break")
+
+func (d *cborDecoder) decode() (any, error) {
+
+	c, err := d.r.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	major, info := c&0xe0, c&0x1f
+
+	if major == cborSimple {
+		return d.decodeSimple(info)
+	}
+
+	if info == cborIndefinite {
+		return d.decodeIndefinite(major)
+	}
+
+	var x uint64
+	switch {
+	case info < 24:
+		x = uint64(info)
+	case info <= 27:
+		if x, err = d.r.readUint(1 << (info - 24)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cbor: invalid additional information %d", info)
+	}
+
+	switch major {
+
+	case cborUint:
+		return x, nil
+
+	case cborNegInt:
+		if x > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: negative integer overflows int64")
+		}
+		return -1 - int64(x), nil
+
+	case cborBytes:
+		return d.r.readBytes(x)
+
+	case cborText:
+		b, err := d.r.readBytes(x)
+		return string(b), err
+
+	case cborArray:
+		a := make([]any, 0, min(x, 1024))
+		for range x {
+			elem, err := d.decodeElem()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, elem)
+		}
+		return a, nil
+
+	case cborMap:
+		m := make(binMap, 0, min(x, 1024))
+		for range x {
+			key, err := d.decodeElem()
+			if err != nil {
+				return nil, err
+			}
+			val, err := d.decodeElem()
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, binPair{key, val})
+		}
+		return m, nil
+
+	default: // cborTag
+		return d.decodeElem()
+	}
+}
+
+// decodeElem decodes an item nested in another.
+func (d *cborDecoder) decodeElem() (any, error) {
+	if d.depth == binMaxDepth {
+		return nil, fmt.Errorf("cbor: exceeded max depth of %d", binMaxDepth)
+	}
+	d.depth++
+	x, err := d.decode()
+	d.depth--
+	return x, noEOF(err)
+}
+
+func (d *cborDecoder) decodeSimple(info byte) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null, undefined
+		return nil, nil
+	case 25:
+		u, err := d.r.readUint(2)
+		return halfToFloat64(uint16(u)), err
+	case 26:
+		u, err := d.r.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 27:
+		u, err := d.r.readUint(8)
+		return math.Float64frombits(u), err
+	case cborIndefinite:
+		return nil, errCBORBreak
+	default:
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+	}
+}
+
+func (d *cborDecoder) decodeIndefinite(major byte) (any, error) {
+
+	var items []any
+
+	for {
+		x, err := d.decodeElem()
+		if err == errCBORBreak {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, x)
+	}
+
+	switch major {
+
+	case cborBytes, cborText:
+		var b []byte
+		for _, x := range items {
+			switch x := x.(type) {
+			case []byte:
+				b = append(b, x...)
+			case string:
+				b = append(b, x...)
+			default:
+				return nil, fmt.Errorf("cbor: invalid chunk in indefinite-length string")
+			}
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return b, nil
+
+	case cborArray:
+		if items == nil {
+			items = []any{}
+		}
+		return items, nil
+
+	case cborMap:
+		if len(items)%2 != 0 {
+			return nil, fmt.Errorf("cbor: odd number of items in indefinite-length map")
+		}
+		m := make(binMap, 0, len(items)/2)
+		for i := 0; i < len(items); i += 2 {
+			m = append(m, binPair{items[i], items[i+1]})
+		}
+		return m, nil
+
+	default:
+		return nil, fmt.Errorf("cbor: invalid indefinite-length item of major type %d", major>>5)
+	}
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float.
+func halfToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	frac := float64(h & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	default:
+		return sign * math.Ldexp(frac+1024, exp-25)
+	}
+}
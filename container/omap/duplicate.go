@@ -0,0 +1,123 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DuplicatePolicy specifies how [DecodeJSON] and [DecodeYAML]
+// handle keys that appear more than once in the input.
+//
+// To keep every value of duplicate keys,
+// use [DecodeJSONAll] and [DecodeYAMLAll], or decode into a [Multi].
+type DuplicatePolicy uint8
+
+const (
+	// LastWins keeps the last value of a duplicate key
+	// at the position of its first occurrence.
+	LastWins DuplicatePolicy = iota
+
+	// LastWinsMoveToEnd keeps the last value of a duplicate key
+	// at the position of its last occurrence.
+	LastWinsMoveToEnd
+
+	// FirstWins keeps the first value of a duplicate key
+	// and ignores the rest.
+	FirstWins
+
+	// ErrorOnDuplicate fails the decoding with a [*DuplicateKeyError].
+	ErrorOnDuplicate
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case LastWins:
+		return "LastWins"
+	case LastWinsMoveToEnd:
+		return "LastWinsMoveToEnd"
+	case FirstWins:
+		return "FirstWins"
+	case ErrorOnDuplicate:
+		return "ErrorOnDuplicate"
+	default:
+		return fmt.Sprintf("DuplicatePolicy(%d)", p)
+	}
+}
+
+// DuplicateKeyError is returned when decoding or merging
+// with [ErrorOnDuplicate] encounters a key that has already been seen.
+type DuplicateKeyError struct {
+	Key string
+
+	// Line and Column are 1-based,
+	// or zero if the key didn't come from an input.
+	Line   int
+	Column int
+
+	// Offset is the byte offset of the key in JSON input,
+	// or -1 otherwise.
+	Offset int64
+}
+
+func (e *DuplicateKeyError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("duplicate key %q", e.Key)
+	}
+	return fmt.Sprintf(
+		"duplicate key %q at line %d, column %d",
+		e.Key, e.Line, e.Column,
+	)
+}
+
+func newJSONDuplicateKeyError(b []byte, offset int64, key string) error {
+
+	// offset is where the previous token ended,
+	// so skip the separators preceding the key.
+	for offset < int64(len(b)) && strings.IndexByte(" \t\r\n,", b[offset]) != -1 {
+		offset++
+	}
+
+	line := bytes.Count(b[:offset], []byte("\n")) + 1
+	column := int(offset) - (bytes.LastIndexByte(b[:offset], '\n') + 1) + 1
+
+	return &DuplicateKeyError{
+		Key:    key,
+		Line:   line,
+		Column: column,
+		Offset: offset,
+	}
+}
+
+// decodeSet stores a decoded key-value pair in m according to policy,
+// recording the keys seen so far in seen.
+// It returns false if key is a duplicate
+// and policy is [ErrorOnDuplicate].
+func (m *Map[K, V]) decodeSet(key K, val V, policy DuplicatePolicy, seen map[K]struct{}) bool {
+
+	if _, dup := seen[key]; !dup {
+		seen[key] = struct{}{}
+		m.Set(key, val)
+		return true
+	}
+
+	switch policy {
+	case LastWins:
+		m.Set(key, val)
+	case LastWinsMoveToEnd:
+		i := m.index(key)
+		m.s = slices.Delete(m.s, i, i+1)
+		m.s = append(m.s, tuple[K, V]{key: key, val: val})
+	case FirstWins:
+	case ErrorOnDuplicate:
+		return false
+	default:
+		panic(fmt.Sprintf("omap: unknown duplicate policy %d", policy))
+	}
+
+	return true
+}
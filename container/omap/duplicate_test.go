@@ -0,0 +1,152 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+	"go.yaml.in/yaml/v4"
+)
+
+// duplicateDecoders decode the same sources as JSON and as YAML,
+// JSON being valid YAML.
+var duplicateDecoders = []struct {
+	name   string
+	decode func(src string, m *omap.Map[string, int], policy omap.DuplicatePolicy) error
+	all    func(src string, m *omap.Map[string, []int]) error
+}{
+	{
+		"json",
+		func(src string, m *omap.Map[string, int], policy omap.DuplicatePolicy) error {
+			return omap.DecodeJSON([]byte(src), m, policy)
+		},
+		func(src string, m *omap.Map[string, []int]) error {
+			return omap.DecodeJSONAll([]byte(src), m)
+		},
+	},
+	{
+		"yaml",
+		func(src string, m *omap.Map[string, int], policy omap.DuplicatePolicy) error {
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(src), &node); err != nil {
+				return err
+			}
+			return omap.DecodeYAML(&node, m, policy)
+		},
+		func(src string, m *omap.Map[string, []int]) error {
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(src), &node); err != nil {
+				return err
+			}
+			return omap.DecodeYAMLAll(&node, m)
+		},
+	},
+}
+
+func TestDuplicatePolicies(t *testing.T) {
+
+	const src = `{"a": 1, "b": 2, "a": 3, "c": 4, "a": 5}`
+
+	tests := []struct {
+		policy omap.DuplicatePolicy
+		want   []kv
+	}{
+		{omap.LastWins, []kv{{"a", "5"}, {"b", "2"}, {"c", "4"}}},
+		{omap.LastWinsMoveToEnd, []kv{{"b", "2"}, {"c", "4"}, {"a", "5"}}},
+		{omap.FirstWins, []kv{{"a", "1"}, {"b", "2"}, {"c", "4"}}},
+	}
+
+	for _, d := range duplicateDecoders {
+
+		for _, tt := range tests {
+			var m omap.Map[string, int]
+			if err := d.decode(src, &m, tt.policy); err != nil {
+				t.Fatalf("%s, %v: %v", d.name, tt.policy, err)
+			}
+			if diff := cmp.Diff(tt.want, kvs(m)); diff != "" {
+				t.Errorf("%s, %v: incorrect result (-want +got):\n%s", d.name, tt.policy, diff)
+			}
+		}
+
+		var all omap.Map[string, []int]
+		if err := d.all(src, &all); err != nil {
+			t.Fatalf("%s, all: %v", d.name, err)
+		}
+		want := []kv{{"a", "[1 3 5]"}, {"b", "[2]"}, {"c", "[4]"}}
+		if diff := cmp.Diff(want, kvs(all)); diff != "" {
+			t.Errorf("%s, all: incorrect result (-want +got):\n%s", d.name, diff)
+		}
+	}
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+
+	tests := []struct {
+		src          string
+		key          string
+		line, column int
+		offset       int64
+	}{
+		{`{"a": 1, "a": 2}`, "a", 1, 10, 9},
+		{"{\n  \"x\": 1,\n  \"y\": 2,\n  \"x\": 3\n}", "x", 4, 3, 24},
+		{"{\"é\": 1,\n\t\"é\": 2}", "é", 2, 2, 11},
+	}
+
+	for _, tt := range tests {
+		for _, d := range duplicateDecoders {
+
+			var m omap.Map[string, int]
+			err := d.decode(tt.src, &m, omap.ErrorOnDuplicate)
+
+			var dup *omap.DuplicateKeyError
+			if !errors.As(err, &dup) {
+				t.Fatalf("%s: %q: error = %v, want a DuplicateKeyError", d.name, tt.src, err)
+			}
+
+			want := omap.DuplicateKeyError{Key: tt.key, Line: tt.line, Column: tt.column, Offset: -1}
+			if d.name == "json" {
+				want.Offset = tt.offset
+			}
+			if diff := cmp.Diff(want, *dup); diff != "" {
+				t.Errorf("%s: %q: incorrect result (-want +got):\n%s", d.name, tt.src, diff)
+			}
+		}
+	}
+
+	err := &omap.DuplicateKeyError{Key: "k", Line: 2, Column: 5}
+	if got, want := err.Error(), `duplicate key "k" at line 2, column 5`; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+	err = &omap.DuplicateKeyError{Key: "k", Offset: -1}
+	if got, want := err.Error(), `duplicate key "k"`; got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
+
+func TestDuplicatePolicyString(t *testing.T) {
+	tests := map[omap.DuplicatePolicy]string{
+		omap.LastWins:          "LastWins",
+		omap.LastWinsMoveToEnd: "LastWinsMoveToEnd",
+		omap.FirstWins:         "FirstWins",
+		omap.ErrorOnDuplicate:  "ErrorOnDuplicate",
+		42:                     "DuplicatePolicy(42)",
+	}
+	for p, want := range tests {
+		if got := p.String(); got != want {
+			t.Errorf("String = %q, want %q", got, want)
+		}
+	}
+}
+
+func kvs[V any](m omap.Map[string, V]) []kv {
+	p := []kv{}
+	for k, v := range m.All() {
+		p = append(p, kv{k, fmt.Sprint(v)})
+	}
+	return p
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Clone returns a copy of m that doesn't share its storage with m.
+// The keys and values themselves are copied shallowly,
+// while the YAML nodes m was decoded from are copied deeply,
+// so that the output of [Map.MarshalYAML] for the copy
+// can be modified without affecting m.
+func (m Map[K, V]) Clone() Map[K, V] {
+	if m.IsNil() {
+		return m
+	}
+	c := New[K, V]()
+	c.s = slices.Clone(m.s)
+	c.yaml = m.yaml.clone()
+	return c
+}
+
+// Merge sets the key-value pairs of other in m,
+// handling the keys that already exist in m according to policy.
+//
+// With [ErrorOnDuplicate], m is left unchanged
+// and a [*DuplicateKeyError] is returned if the maps share a key.
+// Merge panics if policy is not one of the defined policies.
+func (m *Map[K, V]) Merge(other Map[K, V], policy DuplicatePolicy) error {
+
+	switch policy {
+	case LastWins, LastWinsMoveToEnd, FirstWins, ErrorOnDuplicate:
+	default:
+		panic(fmt.Sprintf("omap: unknown duplicate policy %d", policy))
+	}
+
+	m.init()
+
+	if other.IsNil() {
+		return nil
+	}
+
+	if policy == ErrorOnDuplicate {
+		for _, t := range other.s {
+			if m.index(t.key) != -1 {
+				return &DuplicateKeyError{
+					Key:    fmt.Sprint(t.key),
+					Offset: -1,
+				}
+			}
+		}
+	}
+
+	// Merging m into itself would otherwise
+	// move pairs under the loop.
+	src := other.s
+	if other.omap == m.omap {
+		src = slices.Clone(src)
+	}
+
+	for _, t := range src {
+		i := m.index(t.key)
+		switch {
+		case i == -1:
+			m.s = append(m.s, t)
+		case policy == LastWins:
+			m.s[i].val = t.val
+		case policy == LastWinsMoveToEnd:
+			m.s = slices.Delete(m.s, i, i+1)
+			m.s = append(m.s, t)
+		}
+	}
+
+	return nil
+}
+
+// Insert sets the key-value pairs from seq in m.
+func (m *Map[K, V]) Insert(seq iter.Seq2[K, V]) {
+	m.init()
+	for k, v := range seq {
+		m.Set(k, v)
+	}
+}
+
+// DeleteFunc removes the key-value pairs for which del returns true
+// and returns the number of removed pairs.
+func (m *Map[K, V]) DeleteFunc(del func(K, V) bool) int {
+	if m.IsNil() {
+		return 0
+	}
+	n := len(m.s)
+	m.s = slices.DeleteFunc(m.s, func(t tuple[K, V]) bool {
+		return del(t.key, t.val)
+	})
+	return n - len(m.s)
+}
+
+// Filter returns a new map of the key-value pairs
+// for which keep returns true, in the same order.
+func (m Map[K, V]) Filter(keep func(K, V) bool) Map[K, V] {
+	x := New[K, V]()
+	for k, v := range m.All() {
+		if keep(k, v) {
+			x.s = append(x.s, tuple[K, V]{k, v})
+		}
+	}
+	return x
+}
+
+// MapValues returns a new map with the keys of m in the same order
+// and the values of m transformed by fn.
+func MapValues[K comparable, V, W any](m Map[K, V], fn func(K, V) W) Map[K, W] {
+	x := New[K, W](m.Len())
+	for k, v := range m.All() {
+		x.s = append(x.s, tuple[K, W]{k, fn(k, v)})
+	}
+	return x
+}
+
+// Collect returns a new map of the key-value pairs from seq.
+// Repeated keys keep the position of their first occurrence
+// and the value of their last one, as with [Map.Set].
+func Collect[K comparable, V any](seq iter.Seq2[K, V]) Map[K, V] {
+	m := New[K, V]()
+	m.Insert(seq)
+	return m
+}
+
+// FromSeq2 returns a new map of the key-value pairs from seq,
+// handling repeated keys according to policy.
+func FromSeq2[K comparable, V any](seq iter.Seq2[K, V], policy DuplicatePolicy) (Map[K, V], error) {
+	m := New[K, V]()
+	seen := make(map[K]struct{})
+	for k, v := range seq {
+		if !m.decodeSet(k, v, policy, seen) {
+			return m, &DuplicateKeyError{
+				Key:    fmt.Sprint(k),
+				Offset: -1,
+			}
+		}
+	}
+	return m, nil
+}
+
+// Ordering specifies whether [Equal] and [EqualFunc]
+// take the order of the keys into account.
+type Ordering uint8
+
+const (
+	OrderSensitive Ordering = iota
+	OrderInsensitive
+)
+
+// Equal reports whether a and b contain the same key-value pairs.
+// A nil map and an empty map are considered equal.
+func Equal[K, V comparable](a, b Map[K, V], order Ordering) bool {
+	return EqualFunc(a, b, order, func(x, y V) bool {
+		return x == y
+	})
+}
+
+// EqualFunc is like [Equal], but compares values using eq.
+func EqualFunc[K comparable, V1, V2 any](
+	a Map[K, V1],
+	b Map[K, V2],
+	order Ordering,
+	eq func(V1, V2) bool,
+) bool {
+
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	if a.Len() == 0 {
+		return true
+	}
+
+	if order == OrderSensitive {
+		for i := range a.s {
+			if a.s[i].key != b.s[i].key || !eq(a.s[i].val, b.s[i].val) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, t := range a.s {
+		v, ok := b.Get(t.key)
+		if !ok || !eq(t.val, v) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,237 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+// mapOf returns a map of the given keys and values, alternating.
+func mapOf(kv ...int) omap.Map[int, int] {
+	m := omap.New[int, int]()
+	for i := 0; i < len(kv); i += 2 {
+		m.Set(kv[i], kv[i+1])
+	}
+	return m
+}
+
+func TestMerge(t *testing.T) {
+
+	tests := []struct {
+		title  string
+		policy omap.DuplicatePolicy
+		want   []pair
+	}{
+		{"LastWins", omap.LastWins, []pair{{1, 10}, {2, 20}, {3, 30}, {4, 40}}},
+		{"LastWinsMoveToEnd", omap.LastWinsMoveToEnd, []pair{{1, 10}, {3, 30}, {2, 20}, {4, 40}}},
+		{"FirstWins", omap.FirstWins, []pair{{1, 10}, {2, 2}, {3, 30}, {4, 40}}},
+	}
+
+	for _, tt := range tests {
+		m := mapOf(1, 10, 2, 2, 3, 30)
+		if err := m.Merge(mapOf(2, 20, 4, 40), tt.policy); err != nil {
+			t.Fatalf("%s: %v", tt.title, err)
+		}
+		if diff := cmp.Diff(tt.want, pairs(m)); diff != "" {
+			t.Errorf("%s: incorrect result (-want +got):\n%s", tt.title, diff)
+		}
+	}
+
+	// ErrorOnDuplicate leaves m unchanged.
+	m := mapOf(1, 10, 2, 2)
+	err := m.Merge(mapOf(3, 30, 2, 20), omap.ErrorOnDuplicate)
+	var dup *omap.DuplicateKeyError
+	if !errors.As(err, &dup) || dup.Key != "2" || dup.Line != 0 || dup.Offset != -1 {
+		t.Errorf("ErrorOnDuplicate: error = %#v", err)
+	}
+	if diff := cmp.Diff([]pair{{1, 10}, {2, 2}}, pairs(m)); diff != "" {
+		t.Errorf("ErrorOnDuplicate: incorrect result (-want +got):\n%s", diff)
+	}
+	if err := m.Merge(mapOf(3, 30), omap.ErrorOnDuplicate); err != nil {
+		t.Errorf("ErrorOnDuplicate without duplicates: %v", err)
+	}
+
+	// Merging a map into itself leaves it unchanged.
+	for _, policy := range []omap.DuplicatePolicy{omap.LastWins, omap.LastWinsMoveToEnd, omap.FirstWins} {
+		m := mapOf(1, 10, 2, 20, 3, 30)
+		if err := m.Merge(m, policy); err != nil {
+			t.Fatalf("self %v: %v", policy, err)
+		}
+		if diff := cmp.Diff([]pair{{1, 10}, {2, 20}, {3, 30}}, pairs(m)); diff != "" {
+			t.Errorf("self %v: incorrect result (-want +got):\n%s", policy, diff)
+		}
+	}
+
+	// Nil maps.
+	var n omap.Map[int, int]
+	if err := n.Merge(mapOf(1, 10), omap.LastWins); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]pair{{1, 10}}, pairs(n)); diff != "" {
+		t.Errorf("nil receiver: incorrect result (-want +got):\n%s", diff)
+	}
+	if err := n.Merge(omap.Map[int, int]{}, omap.ErrorOnDuplicate); err != nil {
+		t.Errorf("nil argument: %v", err)
+	}
+}
+
+func TestMergeUnknownPolicy(t *testing.T) {
+	m := mapOf(1, 10)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Merge with an unknown policy didn't panic")
+			}
+		}()
+		m.Merge(mapOf(1, 20, 2, 20), omap.DuplicatePolicy(100))
+	}()
+	if diff := cmp.Diff([]pair{{1, 10}}, pairs(m)); diff != "" {
+		t.Errorf("incorrect result after panic (-want +got):\n%s", diff)
+	}
+}
+
+func TestFilter(t *testing.T) {
+
+	m := mapOf(1, 10, 2, 20, 3, 30, 4, 40)
+	f := m.Filter(func(k, v int) bool { return k%2 == 0 || v == 10 })
+
+	if diff := cmp.Diff([]pair{{1, 10}, {2, 20}, {4, 40}}, pairs(f)); diff != "" {
+		t.Errorf("Filter: incorrect result (-want +got):\n%s", diff)
+	}
+
+	f.Set(5, 50)
+	if m.Len() != 4 {
+		t.Error("Filter shares its entries with the receiver")
+	}
+
+	var n omap.Map[int, int]
+	if f := n.Filter(func(int, int) bool { return true }); f.IsNil() || f.Len() != 0 {
+		t.Error("Filter of nil map isn't an empty map")
+	}
+}
+
+func TestMapValues(t *testing.T) {
+
+	m := mapOf(3, 1, 1, 2, 2, 3)
+	got := omap.MapValues(m, func(k, v int) string {
+		return string(rune('a'+k)) + string(rune('0'+v))
+	})
+
+	want := []string{"d1", "b2", "c3"}
+	if diff := cmp.Diff(want, slices.Collect(got.Values())); diff != "" {
+		t.Errorf("MapValues: incorrect result (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{3, 1, 2}, slices.Collect(got.Keys())); diff != "" {
+		t.Errorf("MapValues keys: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestEqual(t *testing.T) {
+
+	a := mapOf(1, 10, 2, 20)
+	b := mapOf(2, 20, 1, 10)
+
+	tests := []struct {
+		title string
+		a, b  omap.Map[int, int]
+		order omap.Ordering
+		want  bool
+	}{
+		{"same order", a, a.Clone(), omap.OrderSensitive, true},
+		{"other order", a, b, omap.OrderSensitive, false},
+		{"other order, insensitive", a, b, omap.OrderInsensitive, true},
+		{"other value", a, mapOf(1, 10, 2, 21), omap.OrderInsensitive, false},
+		{"other key", a, mapOf(1, 10, 3, 20), omap.OrderInsensitive, false},
+		{"other length", a, mapOf(1, 10), omap.OrderInsensitive, false},
+		{"nil and empty", omap.Map[int, int]{}, omap.New[int, int](), omap.OrderSensitive, true},
+		{"nil and non-empty", omap.Map[int, int]{}, a, omap.OrderInsensitive, false},
+	}
+
+	for _, tt := range tests {
+		if got := omap.Equal(tt.a, tt.b, tt.order); got != tt.want {
+			t.Errorf("%s: Equal = %v, want %v", tt.title, got, tt.want)
+		}
+		if got := omap.Equal(tt.b, tt.a, tt.order); got != tt.want {
+			t.Errorf("%s: Equal reversed = %v, want %v", tt.title, got, tt.want)
+		}
+	}
+
+	s := omap.MapValues(b, func(_, v int) string { return string(rune('0' + v/10)) })
+	eq := func(x int, y string) bool { return string(rune('0'+x/10)) == y }
+	if !omap.EqualFunc(a, s, omap.OrderInsensitive, eq) {
+		t.Error("EqualFunc, insensitive = false, want true")
+	}
+	if omap.EqualFunc(a, s, omap.OrderSensitive, eq) {
+		t.Error("EqualFunc, sensitive = true, want false")
+	}
+}
+
+func TestFromSeq2(t *testing.T) {
+
+	seq := func(yield func(int, int) bool) {
+		for _, p := range []pair{{1, 10}, {2, 20}, {1, 11}, {3, 30}} {
+			if !yield(p.K, p.V) {
+				return
+			}
+		}
+	}
+
+	tests := []struct {
+		policy omap.DuplicatePolicy
+		want   []pair
+	}{
+		{omap.LastWins, []pair{{1, 11}, {2, 20}, {3, 30}}},
+		{omap.LastWinsMoveToEnd, []pair{{2, 20}, {1, 11}, {3, 30}}},
+		{omap.FirstWins, []pair{{1, 10}, {2, 20}, {3, 30}}},
+	}
+
+	for _, tt := range tests {
+		m, err := omap.FromSeq2(seq, tt.policy)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.policy, err)
+		}
+		if diff := cmp.Diff(tt.want, pairs(m)); diff != "" {
+			t.Errorf("%v: incorrect result (-want +got):\n%s", tt.policy, diff)
+		}
+	}
+
+	m, err := omap.FromSeq2(seq, omap.ErrorOnDuplicate)
+	var dup *omap.DuplicateKeyError
+	if !errors.As(err, &dup) || dup.Key != "1" {
+		t.Errorf("ErrorOnDuplicate: error = %v", err)
+	}
+	if diff := cmp.Diff([]pair{{1, 10}, {2, 20}}, pairs(m)); diff != "" {
+		t.Errorf("ErrorOnDuplicate: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Collect is like FromSeq2 with LastWins.
+	if diff := cmp.Diff(tests[0].want, pairs(omap.Collect(seq))); diff != "" {
+		t.Errorf("Collect: incorrect result (-want +got):\n%s", diff)
+	}
+	if m := omap.Collect(maps.All(map[int]int{})); m.IsNil() || m.Len() != 0 {
+		t.Error("Collect of an empty sequence isn't an empty map")
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+
+	m := mapOf(1, 10, 2, 20, 3, 30)
+	if n := m.DeleteFunc(func(k, _ int) bool { return k != 2 }); n != 2 {
+		t.Errorf("DeleteFunc = %d, want 2", n)
+	}
+	if diff := cmp.Diff([]pair{{2, 20}}, pairs(m)); diff != "" {
+		t.Errorf("DeleteFunc: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var n omap.Map[int, int]
+	if n.DeleteFunc(func(int, int) bool { return true }) != 0 {
+		t.Error("DeleteFunc on nil map deleted something")
+	}
+}
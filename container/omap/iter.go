@@ -0,0 +1,158 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"iter"
+	"slices"
+)
+
+// Backward returns an iterator over the key-value pairs of the map
+// in reverse insertion order.
+func (m Map[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.IsNil() {
+			return
+		}
+		for i := len(m.s) - 1; i >= 0; i-- {
+			if !yield(m.s[i].key, m.s[i].val) {
+				return
+			}
+		}
+	}
+}
+
+// From returns an iterator over the key-value pairs of the map,
+// starting at key (inclusive) and going forward.
+// It does not iterate if key is not in the map.
+func (m Map[K, V]) From(key K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.IsNil() {
+			return
+		}
+		i := m.index(key)
+		if i == -1 {
+			return
+		}
+		m.forward(i, len(m.s), yield)
+	}
+}
+
+// FromIndex returns an iterator over the key-value pairs of the map,
+// starting at the i'th pair (inclusive) and going forward.
+//
+// It panics if i is negative.
+// It does not iterate if i >= m.Len().
+func (m Map[K, V]) FromIndex(i int) iter.Seq2[K, V] {
+	if i < 0 {
+		panic("omap.Map.FromIndex: i < 0")
+	}
+	return func(yield func(K, V) bool) {
+		if m.IsNil() || i >= len(m.s) {
+			return
+		}
+		m.forward(i, len(m.s), yield)
+	}
+}
+
+// Between returns an iterator over the key-value pairs of the map
+// from startKey to endKey (both inclusive).
+//
+// If endKey comes before startKey, the pairs are iterated backward.
+// It does not iterate if either of the keys is not in the map.
+func (m Map[K, V]) Between(startKey, endKey K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.IsNil() {
+			return
+		}
+		i, j := m.index(startKey), m.index(endKey)
+		if i == -1 || j == -1 {
+			return
+		}
+		if i <= j {
+			m.forward(i, j+1, yield)
+			return
+		}
+		for ; i >= j; i-- {
+			if !yield(m.s[i].key, m.s[i].val) {
+				return
+			}
+		}
+	}
+}
+
+// Entry is a key-value pair yielded by [Map.Entries].
+type Entry[K comparable, V any] struct {
+	m       Map[K, V]
+	i       int
+	key     K
+	deleted bool
+}
+
+// Key returns the key of the entry,
+// even if the entry has been deleted.
+func (e *Entry[K, V]) Key() K {
+	return e.key
+}
+
+// Value returns the value of the entry.
+// It returns the zero value if the entry has been deleted.
+func (e *Entry[K, V]) Value() (val V) {
+	if e.deleted {
+		return val
+	}
+	return e.m.s[e.i].val
+}
+
+// SetValue replaces the value of the entry in the map.
+// It panics if the entry has been deleted.
+func (e *Entry[K, V]) SetValue(val V) {
+	if e.deleted {
+		panic("omap.Entry.SetValue: entry has been deleted")
+	}
+	e.m.s[e.i].val = val
+}
+
+// Delete removes the entry from the map.
+// Iteration continues with the next entry as if nothing had happened.
+func (e *Entry[K, V]) Delete() {
+	if e.deleted {
+		return
+	}
+	e.m.s = slices.Delete(e.m.s, e.i, e.i+1)
+	e.deleted = true
+}
+
+// Entries returns an iterator over the entries of the map
+// that allows deleting the current entry via [Entry.Delete]
+// without disturbing the iteration.
+//
+// The yielded entry is only valid until the next iteration,
+// and the map must not be otherwise modified during the iteration.
+func (m Map[K, V]) Entries() iter.Seq[*Entry[K, V]] {
+	return func(yield func(*Entry[K, V]) bool) {
+		if m.IsNil() {
+			return
+		}
+		e := &Entry[K, V]{m: m}
+		for e.i < len(m.s) {
+			e.key = m.s[e.i].key
+			e.deleted = false
+			if !yield(e) {
+				return
+			}
+			if !e.deleted {
+				e.i++
+			}
+		}
+	}
+}
+
+func (m Map[K, V]) forward(i, j int, yield func(K, V) bool) {
+	for ; i < j; i++ {
+		if !yield(m.s[i].key, m.s[i].val) {
+			return
+		}
+	}
+}
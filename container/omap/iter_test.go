@@ -0,0 +1,101 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"iter"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func TestIterators(t *testing.T) {
+
+	m := omap.New[string, int]()
+	for i, k := range []string{"a", "b", "c", "d"} {
+		m.Set(k, i)
+	}
+
+	tests := []struct {
+		name string
+		seq  iter.Seq2[string, int]
+		want []string
+	}{
+		{"Backward", m.Backward(), []string{"d", "c", "b", "a"}},
+		{"From", m.From("b"), []string{"b", "c", "d"}},
+		{"From missing", m.From("x"), nil},
+		{"FromIndex", m.FromIndex(3), []string{"d"}},
+		{"FromIndex past end", m.FromIndex(4), nil},
+		{"Between", m.Between("b", "c"), []string{"b", "c"}},
+		{"Between same", m.Between("c", "c"), []string{"c"}},
+		{"Between reverse", m.Between("d", "b"), []string{"d", "c", "b"}},
+		{"Between missing start", m.Between("x", "b"), nil},
+		{"Between missing end", m.Between("a", "x"), nil},
+		{"nil Backward", omap.Map[string, int]{}.Backward(), nil},
+		{"nil Between", omap.Map[string, int]{}.Between("a", "b"), nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for k, v := range tt.seq {
+			if want, _ := m.Get(k); v != want {
+				t.Errorf("%s: value of %q is %d, want %d", tt.name, k, v, want)
+			}
+			got = append(got, k)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: incorrect result (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	// Iteration stops when yield returns false.
+	for range m.Between("d", "a") {
+		break
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("FromIndex(-1) didn't panic")
+		}
+	}()
+	m.FromIndex(-1)
+}
+
+func TestEntries(t *testing.T) {
+
+	m := omap.New[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	var keys []string
+	for e := range m.Entries() {
+		if e.Key() != "b" {
+			e.Delete()
+		}
+		// The key is still available after deletion.
+		keys = append(keys, e.Key())
+		if e.Key() != "b" && e.Value() != 0 {
+			t.Errorf("Value of deleted entry %q = %d, want 0", e.Key(), e.Value())
+		}
+	}
+
+	if diff := cmp.Diff([]string{"a", "b", "c"}, keys); diff != "" {
+		t.Errorf("Entries: incorrect keys (-want +got):\n%s", diff)
+	}
+	if got := m.String(); got != "omap[b:2]" {
+		t.Errorf("map after deletions: got %s", got)
+	}
+
+	// Deleting the only entry.
+	one := omap.New[string, int]()
+	one.Set("x", 1)
+	for e := range one.Entries() {
+		e.Delete()
+		if e.Key() != "x" {
+			t.Errorf("Key after Delete = %q, want %q", e.Key(), "x")
+		}
+	}
+}
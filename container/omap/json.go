@@ -5,8 +5,11 @@ package omap
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 
 	"github.com/layer8co/toolbox/must"
@@ -18,10 +21,16 @@ func (m Map[K, V]) MarshalJSON() ([]byte, error) {
 		return []byte("null"), nil
 	}
 
+	return marshalJSONTuples(m.s)
+}
+
+// marshalJSONTuples encodes s as a JSON object.
+func marshalJSONTuples[K comparable, V any](s []tuple[K, V]) ([]byte, error) {
+
 	b := new(bytes.Buffer)
 	b.WriteByte('{')
 
-	for i, t := range m.s {
+	for i, t := range s {
 
 		if i > 0 {
 			b.WriteByte(',')
@@ -59,8 +68,56 @@ func (m Map[K, V]) MarshalJSON() ([]byte, error) {
 }
 
 func (m *Map[K, V]) UnmarshalJSON(b []byte) error {
+	return DecodeJSON(b, m, LastWins)
+}
 
+// DecodeJSON decodes the JSON object b into m,
+// handling duplicate keys according to policy.
+//
+// A JSON null leaves m unchanged, even if it's nil.
+//
+// [Map.UnmarshalJSON] is equivalent to DecodeJSON with [LastWins].
+func DecodeJSON[K comparable, V any](b []byte, m *Map[K, V], policy DuplicatePolicy) error {
+	if isJSONNull(b) {
+		return nil
+	}
 	m.init()
+	seen := make(map[K]struct{})
+	return decodeJSONObject(b, func(key K, val V, offset int64, keyStr string) error {
+		if !m.decodeSet(key, val, policy, seen) {
+			return newJSONDuplicateKeyError(b, offset, keyStr)
+		}
+		return nil
+	})
+}
+
+// DecodeJSONAll decodes the JSON object b into m,
+// keeping the values of duplicate keys in the order they appear.
+// A JSON null leaves m unchanged.
+func DecodeJSONAll[K comparable, V any](b []byte, m *Map[K, []V]) error {
+	if isJSONNull(b) {
+		return nil
+	}
+	m.init()
+	return decodeJSONObject(b, func(key K, val V, _ int64, _ string) error {
+		vals, _ := m.Get(key)
+		m.Set(key, append(vals, val))
+		return nil
+	})
+}
+
+// isJSONNull reports whether b is a JSON null.
+func isJSONNull(b []byte) bool {
+	return string(bytes.TrimSpace(b)) == "null"
+}
+
+// decodeJSONObject calls fn for each key-value pair of the JSON object b,
+// along with the input offset preceding the key and the raw key string.
+// A JSON null is treated as an empty object.
+func decodeJSONObject[K, V any](
+	b []byte,
+	fn func(key K, val V, offset int64, keyStr string) error,
+) error {
 
 	dec := json.NewDecoder(bytes.NewReader(b))
 
@@ -69,6 +126,10 @@ func (m *Map[K, V]) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	if t == nil {
+		return nil
+	}
+
 	delim, ok := t.(json.Delim)
 	if !ok || delim != '{' {
 		return fmt.Errorf("expected '{', got %v", t)
@@ -76,6 +137,8 @@ func (m *Map[K, V]) UnmarshalJSON(b []byte) error {
 
 	for dec.More() {
 
+		offset := dec.InputOffset()
+
 		t, err := dec.Token()
 		if err != nil {
 			return err
@@ -86,22 +149,20 @@ func (m *Map[K, V]) UnmarshalJSON(b []byte) error {
 			return fmt.Errorf("expected string key, got %T", t)
 		}
 
-		var key K
 		var val V
 
-		err = json.Unmarshal(must.Get(json.Marshal(keyStr)), &key)
+		key, err := decodeJSONKey[K](keyStr)
 		if err != nil {
-			return fmt.Errorf(
-				"could not decode key %q into %T: %w",
-				keyStr, key, err,
-			)
+			return err
 		}
 
 		if err := dec.Decode(&val); err != nil {
 			return err
 		}
 
-		m.Set(key, val)
+		if err := fn(key, val, offset, keyStr); err != nil {
+			return err
+		}
 	}
 
 	t, err = dec.Token()
@@ -117,6 +178,22 @@ func (m *Map[K, V]) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// decodeJSONKey decodes the JSON object key s into a K.
+func decodeJSONKey[K any](s string) (key K, err error) {
+	key, err = parseKeyText[K](s)
+	if !errors.Is(err, errUnsupportedKey) {
+		return key, err
+	}
+	err = json.Unmarshal(must.Get(json.Marshal(s)), &key)
+	if err != nil {
+		return key, fmt.Errorf(
+			"could not decode key %q into %T: %w",
+			s, key, err,
+		)
+	}
+	return key, nil
+}
+
 func itoa[T any](v T) string {
 	switch v := any(v).(type) {
 	case int:
@@ -145,3 +222,75 @@ func itoa[T any](v T) string {
 		return ""
 	}
 }
+
+var errUnsupportedKey = errors.New("unsupported key type")
+
+// keyText returns the JSON object key representation of key.
+func keyText[K any](key K) (string, error) {
+
+	switch k := any(key).(type) {
+	case string:
+		return k, nil
+	case encoding.TextMarshaler:
+		b, err := k.MarshalText()
+		return string(b), err
+	}
+
+	v := reflect.ValueOf(key)
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("%w %T", errUnsupportedKey, key)
+	}
+}
+
+// parseKeyText parses a JSON object key into a K.
+func parseKeyText[K any](s string) (key K, err error) {
+
+	switch k := any(&key).(type) {
+	case *string:
+		*k = s
+		return key, nil
+	case encoding.TextUnmarshaler:
+		err := k.UnmarshalText([]byte(s))
+		if err != nil {
+			return key, fmt.Errorf(
+				"could not decode key %q into %T: %w",
+				s, key, err,
+			)
+		}
+		return key, nil
+	}
+
+	v := reflect.ValueOf(&key).Elem()
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+	default:
+		return key, fmt.Errorf("%w %T", errUnsupportedKey, key)
+	}
+
+	if err != nil {
+		return key, fmt.Errorf(
+			"could not decode key %q into %T: %w",
+			s, key, err,
+		)
+	}
+
+	return key, nil
+}
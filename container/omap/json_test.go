@@ -0,0 +1,163 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+// point is a key type that implements
+// [encoding.TextMarshaler] and [encoding.TextUnmarshaler].
+type point struct {
+	X, Y int
+}
+
+func (p point) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "%d,%d", p.X, p.Y), nil
+}
+
+func (p *point) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestJSONTextKeys(t *testing.T) {
+
+	m := omap.New[point, string]()
+	m.Set(point{3, 4}, "a")
+	m.Set(point{-1, 0}, "b")
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"3,4":"a","-1,0":"b"}`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Errorf("json.Marshal: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var got omap.Map[point, string]
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !omap.Equal(m, got, omap.OrderSensitive) {
+		t.Errorf("json.Unmarshal = %v, want %v", got, m)
+	}
+
+	if err := json.Unmarshal([]byte(`{"x":"a"}`), &got); err == nil {
+		t.Error("json.Unmarshal of a malformed key succeeded")
+	}
+
+	// Standard library types.
+	addrs := omap.New[netip.Addr, int]()
+	addrs.Set(netip.MustParseAddr("10.0.0.1"), 1)
+	addrs.Set(netip.MustParseAddr("::1"), 2)
+
+	b, err = json.Marshal(addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`{"10.0.0.1":1,"::1":2}`, string(b)); diff != "" {
+		t.Errorf("json.Marshal of addresses: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var gotAddrs omap.Map[netip.Addr, int]
+	if err := json.Unmarshal(b, &gotAddrs); err != nil {
+		t.Fatal(err)
+	}
+	if !omap.Equal(addrs, gotAddrs, omap.OrderSensitive) {
+		t.Errorf("json.Unmarshal of addresses = %v, want %v", gotAddrs, addrs)
+	}
+}
+
+func TestJSONKeys(t *testing.T) {
+
+	ints := omap.New[int8, bool]()
+	ints.Set(-5, true)
+	ints.Set(7, false)
+
+	b, err := json.Marshal(ints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`{"-5":true,"7":false}`, string(b)); diff != "" {
+		t.Errorf("json.Marshal of int keys: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var got omap.Map[int8, bool]
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !omap.Equal(ints, got, omap.OrderSensitive) {
+		t.Errorf("json.Unmarshal of int keys = %v, want %v", got, ints)
+	}
+
+	if err := json.Unmarshal([]byte(`{"300":true}`), &got); err == nil {
+		t.Error("json.Unmarshal of an out-of-range key succeeded")
+	}
+
+	// Keys of other types are not supported.
+	type pair struct{ A, B int }
+	structs := omap.New[pair, int]()
+	structs.Set(pair{1, 2}, 3)
+	if _, err := json.Marshal(structs); err == nil {
+		t.Error("json.Marshal of struct keys succeeded")
+	}
+	var gotStructs omap.Map[pair, int]
+	err = json.Unmarshal([]byte(`{"x":1}`), &gotStructs)
+	var syntaxErr *json.SyntaxError
+	if err == nil || errors.As(err, &syntaxErr) {
+		t.Errorf("json.Unmarshal of struct keys: error = %v", err)
+	}
+}
+
+func TestJSONNull(t *testing.T) {
+
+	m := mapOf(1, 10)
+	if err := json.Unmarshal([]byte(`null`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]pair{{1, 10}}, pairs(m)); diff != "" {
+		t.Errorf("json.Unmarshal of null: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Nil maps stay nil, and empty objects allocate them.
+	var n omap.Map[int, int]
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &n); err != nil || !n.IsNil() {
+		t.Errorf("json.Unmarshal(%s) = %v, %v; want a nil map", b, n, err)
+	}
+	if err := n.UnmarshalJSON([]byte(" null ")); err != nil || !n.IsNil() {
+		t.Errorf("UnmarshalJSON(null) = %v, %v; want a nil map", n, err)
+	}
+	if err := omap.DecodeJSON([]byte("null"), &n, omap.ErrorOnDuplicate); err != nil || !n.IsNil() {
+		t.Errorf("DecodeJSON(null) = %v, %v; want a nil map", n, err)
+	}
+	var all omap.Map[int, []int]
+	if err := omap.DecodeJSONAll([]byte("null"), &all); err != nil || !all.IsNil() {
+		t.Errorf("DecodeJSONAll(null) = %v, %v; want a nil map", all, err)
+	}
+	var multi omap.Multi[int, int]
+	if err := json.Unmarshal([]byte("null"), &multi); err != nil || !multi.IsNil() {
+		t.Errorf("json.Unmarshal(null) into Multi = %v, %v; want a nil multimap", multi, err)
+	}
+	var set omap.Set[int]
+	if err := json.Unmarshal([]byte("null"), &set); err != nil || !set.IsNil() {
+		t.Errorf("json.Unmarshal(null) into Set = %v, %v; want a nil set", set, err)
+	}
+
+	if err := json.Unmarshal([]byte("{}"), &n); err != nil || n.IsNil() {
+		t.Errorf("json.Unmarshal({}) = %v, %v; want an empty map", n, err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build go1.27 && goexperiment.jsonv2
+
+package omap
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"fmt"
+)
+
+// MarshalJSONTo implements [json.MarshalerTo],
+// streaming the map to enc without buffering it.
+func (m Map[K, V]) MarshalJSONTo(enc *jsontext.Encoder) error {
+
+	if m.IsNil() {
+		return enc.WriteToken(jsontext.Null)
+	}
+
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}
+
+	opts := enc.Options()
+
+	for _, t := range m.s {
+
+		key, err := keyText(t.key)
+		if err != nil {
+			return err
+		}
+
+		if err := enc.WriteToken(jsontext.String(key)); err != nil {
+			return err
+		}
+
+		if err := json.MarshalEncode(enc, t.val, opts); err != nil {
+			return err
+		}
+	}
+
+	return enc.WriteToken(jsontext.EndObject)
+}
+
+// UnmarshalJSONFrom implements [json.UnmarshalerFrom],
+// streaming the map from dec without buffering it.
+//
+// A JSON null leaves m unchanged.
+func (m *Map[K, V]) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+
+	t, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+
+	switch t.Kind() {
+	case jsontext.KindNull:
+		return nil
+	case jsontext.KindBeginObject:
+	default:
+		return fmt.Errorf("expected '{', got %v", t)
+	}
+
+	m.init()
+	opts := dec.Options()
+
+	for dec.PeekKind() != jsontext.KindEndObject {
+
+		t, err := dec.ReadToken()
+		if err != nil {
+			return err
+		}
+
+		key, err := parseKeyText[K](t.String())
+		if err != nil {
+			return err
+		}
+
+		var val V
+		if err := json.UnmarshalDecode(dec, &val, opts); err != nil {
+			return err
+		}
+
+		m.Set(key, val)
+	}
+
+	_, err = dec.ReadToken()
+	return err
+}
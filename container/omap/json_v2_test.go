@@ -0,0 +1,130 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build go1.27 && goexperiment.jsonv2
+
+package omap_test
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func TestJSONv2(t *testing.T) {
+
+	type doc struct {
+		Name   string
+		Attrs  omap.Map[string, any]
+		Counts omap.Map[string, int]
+		Nil    omap.Map[string, int]
+	}
+
+	in := doc{
+		Name:   "x",
+		Attrs:  omap.New[string, any](),
+		Counts: omap.New[string, int](),
+	}
+	in.Attrs.Set("z", 1.5)
+	in.Attrs.Set("a", []any{"b", true})
+	in.Counts.Set("y", 2)
+	in.Counts.Set("x", 1)
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"Name":"x","Attrs":{"z":1.5,"a":["b",true]},"Counts":{"y":2,"x":1},"Nil":null}`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Errorf("json.Marshal: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var out doc
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Nil.IsNil() {
+		t.Error("null decoded into a non-nil map")
+	}
+	got, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("round trip: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONv2Stream(t *testing.T) {
+
+	// Each map is read from the stream without consuming the next.
+	dec := jsontext.NewDecoder(strings.NewReader(`{"b":1,"a":2} {"3,4":"p"} null [1]`))
+
+	var m omap.Map[string, int]
+	if err := json.UnmarshalDecode(dec, &m); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"b", "a"}, keys(m)); diff != "" {
+		t.Errorf("first map: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var points omap.Map[point, string]
+	if err := json.UnmarshalDecode(dec, &points); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := points.Get(point{3, 4}); v != "p" {
+		t.Errorf("text key: got %v", points)
+	}
+
+	// A null leaves the map unchanged.
+	if err := json.UnmarshalDecode(dec, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 2 {
+		t.Errorf("null changed the map: %v", m)
+	}
+
+	if err := json.UnmarshalDecode(dec, &m); err == nil {
+		t.Error("decoding an array succeeded")
+	}
+
+	// Maps are written to the stream in order.
+	var buf bytes.Buffer
+	enc := jsontext.NewEncoder(&buf)
+	for _, v := range []any{m, points} {
+		if err := json.MarshalEncode(enc, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff("{\"b\":1,\"a\":2}\n{\"3,4\":\"p\"}\n", buf.String()); diff != "" {
+		t.Errorf("encoding: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Truncated input.
+	dec = jsontext.NewDecoder(strings.NewReader(`{"a":1,`))
+	if err := json.UnmarshalDecode(dec, &m); err == nil || err == io.EOF {
+		t.Errorf("truncated input: error = %v", err)
+	}
+}
+
+func TestJSONv2Null(t *testing.T) {
+
+	var m omap.Map[string, int]
+	if err := json.Unmarshal([]byte("null"), &m); err != nil || !m.IsNil() {
+		t.Errorf("json.Unmarshal(null) = %v, %v; want a nil map", m, err)
+	}
+
+	dec := jsontext.NewDecoder(strings.NewReader("null {}"))
+	if err := m.UnmarshalJSONFrom(dec); err != nil || !m.IsNil() {
+		t.Errorf("UnmarshalJSONFrom(null) = %v, %v; want a nil map", m, err)
+	}
+	if err := m.UnmarshalJSONFrom(dec); err != nil || m.IsNil() {
+		t.Errorf("UnmarshalJSONFrom({}) = %v, %v; want an empty map", m, err)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"fmt"
+	"math"
+)
+
+type msgpackEncoder struct {
+	binWriter
+}
+
+func (e *msgpackEncoder) encodeNil() error {
+	return e.writeHeader(0xc0, 0, 0)
+}
+
+func (e *msgpackEncoder) encodeBool(b bool) error {
+	if b {
+		return e.writeHeader(0xc3, 0, 0)
+	}
+	return e.writeHeader(0xc2, 0, 0)
+}
+
+func (e *msgpackEncoder) encodeInt(i int64) error {
+	switch {
+	case i >= 0:
+		return e.encodeUint(uint64(i))
+	case i >= -32:
+		return e.writeHeader(byte(i), 0, 0)
+	case i >= math.MinInt8:
+		return e.writeHeader(0xd0, uint64(i), 1)
+	case i >= math.MinInt16:
+		return e.writeHeader(0xd1, uint64(i), 2)
+	case i >= math.MinInt32:
+		return e.writeHeader(0xd2, uint64(i), 4)
+	default:
+		return e.writeHeader(0xd3, uint64(i), 8)
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(u uint64) error {
+	switch {
+	case u <= 0x7f:
+		return e.writeHeader(byte(u), 0, 0)
+	case u <= math.MaxUint8:
+		return e.writeHeader(0xcc, u, 1)
+	case u <= math.MaxUint16:
+		return e.writeHeader(0xcd, u, 2)
+	case u <= math.MaxUint32:
+		return e.writeHeader(0xce, u, 4)
+	default:
+		return e.writeHeader(0xcf, u, 8)
+	}
+}
+
+func (e *msgpackEncoder) encodeFloat32(f float32) error {
+	return e.writeHeader(0xca, uint64(math.Float32bits(f)), 4)
+}
+
+func (e *msgpackEncoder) encodeFloat64(f float64) error {
+	return e.writeHeader(0xcb, math.Float64bits(f), 8)
+}
+
+func (e *msgpackEncoder) encodeString(s string) error {
+	if err := e.header(len(s), 0xa0, 31, 0xd9, 0xda, 0xdb); err != nil {
+		return err
+	}
+	return e.writeString(s)
+}
+
+func (e *msgpackEncoder) encodeBytes(b []byte) error {
+	if err := e.header(len(b), 0, -1, 0xc4, 0xc5, 0xc6); err != nil {
+		return err
+	}
+	return e.write(b)
+}
+
+func (e *msgpackEncoder) encodeArrayHeader(n int) error {
+	return e.header(n, 0x90, 15, 0, 0xdc, 0xdd)
+}
+
+func (e *msgpackEncoder) encodeMapHeader(n int) error {
+	return e.header(n, 0x80, 15, 0, 0xde, 0xdf)
+}
+
+// header writes a length header, using the fix format
+// if n <= fixMax and the smallest of the 8, 16 and 32 bit formats
+// otherwise. A zero format byte means the format doesn't exist.
+func (e *msgpackEncoder) header(n int, fix byte, fixMax int, b8, b16, b32 byte) error {
+	switch {
+	case n <= fixMax:
+		return e.writeHeader(fix|byte(n), 0, 0)
+	case b8 != 0 && n <= math.MaxUint8:
+		return e.writeHeader(b8, uint64(n), 1)
+	case n <= math.MaxUint16:
+		return e.writeHeader(b16, uint64(n), 2)
+	case uint64(n) <= math.MaxUint32:
+		return e.writeHeader(b32, uint64(n), 4)
+	default:
+		return fmt.Errorf("msgpack: length %d too large", n)
+	}
+}
+
+type msgpackDecoder struct {
+	r     *binReader
+	depth int
+}
+
+func (d *msgpackDecoder) decode() (any, error) {
+
+	c, err := d.r.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(uint64(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(uint64(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.decodeString(uint64(c & 0x1f))
+	}
+
+	switch c {
+
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.r.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.r.readBytes(n)
+
+	case 0xca:
+		u, err := d.r.readUint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.r.readUint(8)
+		return math.Float64frombits(u), err
+
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.r.readUint(1 << (c - 0xcc))
+
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		n := 1 << (c - 0xd0)
+		u, err := d.r.readUint(n)
+		// Sign-extend the n-byte integer.
+		shift := 64 - 8*n
+		return int64(u<<shift) >> shift, err
+
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.r.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+
+	case 0xdc, 0xdd:
+		n, err := d.r.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+
+	case 0xde, 0xdf:
+		n, err := d.r.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported format 0x%02x", c)
+	}
+}
+
+func (d *msgpackDecoder) decodeString(n uint64) (any, error) {
+	b, err := d.r.readBytes(n)
+	return string(b), err
+}
+
+// nest records entering an array or map,
+// returning a function recording leaving it.
+func (d *msgpackDecoder) nest() (func(), error) {
+	if d.depth == binMaxDepth {
+		return nil, fmt.Errorf("msgpack: exceeded max depth of %d", binMaxDepth)
+	}
+	d.depth++
+	return func() { d.depth-- }, nil
+}
+
+func (d *msgpackDecoder) decodeArray(n uint64) (any, error) {
+	leave, err := d.nest()
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	a := make([]any, 0, min(n, 1024))
+	for range n {
+		x, err := d.decode()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		a = append(a, x)
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n uint64) (any, error) {
+	leave, err := d.nest()
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	m := make(binMap, 0, min(n, 1024))
+	for range n {
+		key, err := d.decode()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		val, err := d.decode()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		m = append(m, binPair{key, val})
+	}
+	return m, nil
+}
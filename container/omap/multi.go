@@ -0,0 +1,251 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Multi is an ordered multimap:
+// a key can appear more than once,
+// and the order of all key-value pairs is kept.
+//
+// It's suited for HTTP headers, query strings and the like.
+type Multi[K comparable, V any] struct {
+	*multi[K, V]
+}
+
+type multi[K comparable, V any] struct {
+	s []tuple[K, V]
+}
+
+func NewMulti[K comparable, V any](size ...int) Multi[K, V] {
+	m := Multi[K, V]{
+		multi: new(multi[K, V]),
+	}
+	if len(size) > 0 {
+		m.s = make([]tuple[K, V], 0, size[0])
+	}
+	return m
+}
+
+func InitMulti[K comparable, V any](m *Multi[K, V], size ...int) {
+	if m.IsNil() {
+		*m = NewMulti[K, V](size...)
+	}
+}
+
+func (m Multi[K, V]) IsNil() bool {
+	return m.multi == nil
+}
+
+// Add appends a key-value pair to the multimap.
+func (m *Multi[K, V]) Add(key K, val V) {
+	m.init()
+	m.s = append(m.s, tuple[K, V]{
+		key: key,
+		val: val,
+	})
+}
+
+// Set replaces all the values of key with val,
+// at the position of the first occurrence of key.
+// If key is not in the multimap, the pair is appended.
+func (m *Multi[K, V]) Set(key K, val V) {
+	m.init()
+	i := m.index(key)
+	if i == -1 {
+		m.Add(key, val)
+		return
+	}
+	m.s[i].val = val
+	rest := slices.DeleteFunc(m.s[i+1:], func(t tuple[K, V]) bool {
+		return t.key == key
+	})
+	m.s = m.s[:i+1+len(rest)]
+}
+
+// Get returns the first value of key.
+func (m Multi[K, V]) Get(key K) (val V, has bool) {
+	if m.IsNil() {
+		return val, false
+	}
+	i := m.index(key)
+	if i == -1 {
+		return val, false
+	}
+	return m.s[i].val, true
+}
+
+// GetAll returns the values of key in order.
+// It returns nil if key is not in the multimap.
+func (m Multi[K, V]) GetAll(key K) []V {
+	var vals []V
+	for v := range m.ValuesOf(key) {
+		vals = append(vals, v)
+	}
+	return vals
+}
+
+// DeleteAll removes all the values of key
+// and returns the number of removed pairs.
+func (m *Multi[K, V]) DeleteAll(key K) int {
+	if m.IsNil() {
+		return 0
+	}
+	n := len(m.s)
+	m.s = slices.DeleteFunc(m.s, func(t tuple[K, V]) bool {
+		return t.key == key
+	})
+	return n - len(m.s)
+}
+
+// Len returns the number of key-value pairs in the multimap.
+func (m Multi[K, V]) Len() int {
+	if m.IsNil() {
+		return 0
+	}
+	return len(m.s)
+}
+
+// All returns an iterator over the key-value pairs of the multimap
+// in the order they were added, with repeated keys interleaved.
+func (m Multi[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if m.IsNil() {
+			return
+		}
+		for _, t := range m.s {
+			if !yield(t.key, t.val) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the distinct keys of the multimap
+// in the order of their first occurrence.
+func (m Multi[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		if m.IsNil() {
+			return
+		}
+		seen := make(map[K]struct{})
+		for _, t := range m.s {
+			if _, ok := seen[t.key]; ok {
+				continue
+			}
+			seen[t.key] = struct{}{}
+			if !yield(t.key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesOf returns an iterator over the values of key in order.
+func (m Multi[K, V]) ValuesOf(key K) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		if m.IsNil() {
+			return
+		}
+		for _, t := range m.s {
+			if t.key == key && !yield(t.val) {
+				return
+			}
+		}
+	}
+}
+
+func (m Multi[K, V]) String() string {
+	if m.IsNil() {
+		return "omap.Multi[]"
+	}
+	var sb strings.Builder
+	sb.WriteString("omap.Multi[")
+	for i, t := range m.s {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "%v:%v", t.key, t.val)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// MarshalJSON encodes the multimap as a JSON object
+// in which repeated keys appear once per value.
+func (m Multi[K, V]) MarshalJSON() ([]byte, error) {
+	if m.IsNil() {
+		return []byte("null"), nil
+	}
+	return marshalJSONTuples(m.s)
+}
+
+// UnmarshalJSON decodes a JSON object into the multimap,
+// adding every key-value pair in order, including repeated keys.
+// A JSON null leaves the multimap unchanged.
+func (m *Multi[K, V]) UnmarshalJSON(b []byte) error {
+	if isJSONNull(b) {
+		return nil
+	}
+	m.init()
+	return decodeJSONObject(b, func(key K, val V, _ int64, _ string) error {
+		m.Add(key, val)
+		return nil
+	})
+}
+
+// MarshalYAML encodes the multimap as a YAML mapping node
+// in which repeated keys appear once per value.
+func (m Multi[K, V]) MarshalYAML() (any, error) {
+
+	node := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+
+	for k, v := range m.All() {
+
+		key, err := encodeYAMLNode(k)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := encodeYAMLNode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, key, val)
+	}
+
+	return node, nil
+}
+
+// UnmarshalYAML decodes a YAML mapping node into the multimap,
+// adding every key-value pair in order, including repeated keys.
+func (m *Multi[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	m.init()
+	return decodeYAMLMapping(node, func(key K, val V, _, _ *yaml.Node) error {
+		m.Add(key, val)
+		return nil
+	})
+}
+
+func (m *Multi[K, V]) init() {
+	if m.multi == nil {
+		m.multi = new(multi[K, V])
+	}
+}
+
+func (m Multi[K, V]) index(key K) int {
+	return slices.IndexFunc(m.s, func(t tuple[K, V]) bool {
+		return key == t.key
+	})
+}
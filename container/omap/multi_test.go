@@ -0,0 +1,157 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+	"go.yaml.in/yaml/v4"
+)
+
+type kv struct {
+	K, V string
+}
+
+func multiPairs(m omap.Multi[string, string]) []kv {
+	p := []kv{}
+	for k, v := range m.All() {
+		p = append(p, kv{k, v})
+	}
+	return p
+}
+
+func TestMulti(t *testing.T) {
+
+	var m omap.Multi[string, string]
+
+	if m.Len() != 0 || m.GetAll("a") != nil || m.DeleteAll("a") != 0 {
+		t.Error("nil multimap is not empty")
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Error("Get on nil multimap found a value")
+	}
+
+	m.Add("a", "1")
+	m.Add("b", "2")
+	m.Add("a", "3")
+	m.Add("c", "4")
+	m.Add("a", "5")
+
+	check := func(title string, want []kv) {
+		t.Helper()
+		if diff := cmp.Diff(want, multiPairs(m)); diff != "" {
+			t.Errorf("%s: incorrect result (-want +got):\n%s", title, diff)
+		}
+		if m.Len() != len(want) {
+			t.Errorf("%s: Len = %d, want %d", title, m.Len(), len(want))
+		}
+	}
+
+	check("Add", []kv{{"a", "1"}, {"b", "2"}, {"a", "3"}, {"c", "4"}, {"a", "5"}})
+
+	if v, ok := m.Get("a"); !ok || v != "1" {
+		t.Errorf("Get(a) = %q, %v, want first value", v, ok)
+	}
+	if diff := cmp.Diff([]string{"1", "3", "5"}, m.GetAll("a")); diff != "" {
+		t.Errorf("GetAll: incorrect result (-want +got):\n%s", diff)
+	}
+	if got := slices.Collect(m.ValuesOf("b")); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("ValuesOf(b) = %q", got)
+	}
+	if got := m.GetAll("x"); got != nil {
+		t.Errorf("GetAll(x) = %q, want nil", got)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, slices.Collect(m.Keys())); diff != "" {
+		t.Errorf("Keys: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Stopping early.
+	for range m.Keys() {
+		break
+	}
+	for range m.ValuesOf("a") {
+		break
+	}
+
+	m.Set("a", "6")
+	check("Set collapses repeats", []kv{{"a", "6"}, {"b", "2"}, {"c", "4"}})
+
+	m.Set("d", "7")
+	check("Set appends", []kv{{"a", "6"}, {"b", "2"}, {"c", "4"}, {"d", "7"}})
+
+	m.Add("b", "8")
+	if n := m.DeleteAll("b"); n != 2 {
+		t.Errorf("DeleteAll(b) = %d, want 2", n)
+	}
+	check("DeleteAll", []kv{{"a", "6"}, {"c", "4"}, {"d", "7"}})
+
+	if n := m.DeleteAll("b"); n != 0 {
+		t.Errorf("DeleteAll(b) again = %d, want 0", n)
+	}
+
+	if got, want := m.String(), "omap.Multi[a:6 c:4 d:7]"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestMultiEncoding(t *testing.T) {
+
+	want := []kv{{"a", "1"}, {"b", "2"}, {"a", "3"}, {"é", "4"}, {"a", "5"}}
+
+	t.Run("json", func(t *testing.T) {
+
+		const src = `{"a":"1","b":"2","a":"3","é":"4","a":"5"}`
+
+		var m omap.Multi[string, string]
+		if err := json.Unmarshal([]byte(src), &m); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, multiPairs(m)); diff != "" {
+			t.Errorf("json.Unmarshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(`{"a":"1","b":"2","a":"3","é":"4","a":"5"}`, string(b)); diff != "" {
+			t.Errorf("json.Marshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		var nilMulti omap.Multi[string, string]
+		if b, _ := json.Marshal(nilMulti); string(b) != "null" {
+			t.Errorf("json.Marshal of nil multimap = %s, want null", b)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+
+		const src = "a: \"1\"\nb: \"2\"\na: \"3\"\né: \"4\"\na: \"5\"\n"
+
+		var m omap.Multi[string, string]
+		if err := yaml.Unmarshal([]byte(src), &m); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, multiPairs(m)); diff != "" {
+			t.Errorf("yaml.Unmarshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		b, err := yaml.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var back omap.Multi[string, string]
+		if err := yaml.Unmarshal(b, &back); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, multiPairs(back)); diff != "" {
+			t.Errorf("yaml round trip: incorrect result (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -12,12 +12,19 @@ import (
 )
 
 // Map is an ordered map.
+//
+// A Map is a reference to its entries, so copies of a Map share them.
+// The zero value is a nil map, which reads like an empty map.
+// [Map.Set] allocates a nil map in place, but copies of the map
+// made before that don't see the allocation;
+// use [New] or [Init] to share a map before setting keys in it.
 type Map[K comparable, V any] struct {
 	*omap[K, V]
 }
 
 type omap[K comparable, V any] struct {
-	s []tuple[K, V]
+	s    []tuple[K, V]
+	yaml *yamlSource[K]
 }
 
 type tuple[K comparable, V any] struct {
@@ -35,6 +42,7 @@ func New[K comparable, V any](size ...int) Map[K, V] {
 	return m
 }
 
+// Init allocates m if it's nil, and otherwise leaves it unchanged.
 func Init[K comparable, V any](m *Map[K, V], size ...int) {
 	if m.IsNil() {
 		*m = New[K, V](size...)
@@ -57,6 +65,7 @@ func (m Map[K, V]) Get(key K) (val V, has bool) {
 }
 
 func (m *Map[K, V]) Set(key K, val V) {
+	m.init()
 	i := m.index(key)
 	if i == -1 {
 		m.s = append(m.s, tuple[K, V]{
@@ -76,8 +85,9 @@ func (m *Map[K, V]) Delete(key K) (val V, has bool) {
 	if i == -1 {
 		return val, false
 	}
+	val = m.s[i].val
 	m.s = slices.Delete(m.s, i, i+1)
-	return m.s[i].val, true
+	return val, true
 }
 
 func (m Map[K, V]) Len() int {
@@ -98,6 +108,8 @@ func (m Map[K, V]) Map() map[K]V {
 	return x
 }
 
+// All returns an iterator over the key-value pairs in order.
+// Templates can range over it with {{range $k, $v := .All}}.
 func (m Map[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
 		if m.IsNil() {
@@ -0,0 +1,259 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func TestNilMap(t *testing.T) {
+
+	var m omap.Map[string, int]
+
+	if !m.IsNil() || m.Len() != 0 {
+		t.Fatalf("zero map: IsNil %v, Len %d", m.IsNil(), m.Len())
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("Get on nil map found a key")
+	}
+	if _, ok := m.Delete("a"); ok {
+		t.Fatal("Delete on nil map found a key")
+	}
+	for range m.All() {
+		t.Fatal("All on nil map yielded")
+	}
+	if m.Map() != nil {
+		t.Fatal("Map on nil map is not nil")
+	}
+
+	c := m
+	m.Set("a", 1)
+	if m.IsNil() || m.Len() != 1 {
+		t.Fatal("Set didn't allocate the nil map")
+	}
+	if !c.IsNil() {
+		t.Fatal("Set allocated a copy made before it")
+	}
+
+	omap.Init(&c, 10)
+	omap.Init(&m)
+	if c.IsNil() {
+		t.Fatal("Init didn't allocate the nil map")
+	}
+	if v, _ := m.Get("a"); v != 1 {
+		t.Fatal("Init modified a non-nil map")
+	}
+
+	s := omap.New[string, int]()
+	x := s
+	x.Set("b", 2)
+	if v, _ := s.Get("b"); v != 2 {
+		t.Fatal("copy of non-nil map doesn't share its entries")
+	}
+}
+
+func TestDelete(t *testing.T) {
+
+	m := omap.New[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	for _, key := range []string{"c", "a", "b"} {
+		want, _ := m.Get(key)
+		got, ok := m.Delete(key)
+		if !ok || got != want {
+			t.Fatalf("Delete(%q) = %d, %v; want %d, true", key, got, ok, want)
+		}
+	}
+
+	if m.Len() != 0 {
+		t.Fatalf("Len = %d after deleting every key", m.Len())
+	}
+}
+
+// model is the reference implementation of an ordered map.
+type model struct {
+	keys []int
+	vals map[int]int
+}
+
+func (m *model) set(k, v int) {
+	if _, ok := m.vals[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.vals[k] = v
+}
+
+func (m *model) delete(k int) (int, bool) {
+	v, ok := m.vals[k]
+	if ok {
+		delete(m.vals, k)
+		m.keys = slices.DeleteFunc(m.keys, func(x int) bool { return x == k })
+	}
+	return v, ok
+}
+
+type pair struct {
+	K, V int
+}
+
+func (m *model) pairs() []pair {
+	p := []pair{}
+	for _, k := range m.keys {
+		p = append(p, pair{k, m.vals[k]})
+	}
+	return p
+}
+
+func pairs[M interface {
+	All() iter.Seq2[int, int]
+}](m M) []pair {
+	p := []pair{}
+	for k, v := range m.All() {
+		p = append(p, pair{k, v})
+	}
+	return p
+}
+
+func TestModel(t *testing.T) {
+	for seed := range uint64(50) {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			testModel(t, rand.New(rand.NewPCG(seed, seed)))
+		})
+	}
+}
+
+func testModel(t *testing.T, r *rand.Rand) {
+
+	var m omap.Map[int, int]
+	var p omap.Persistent[int, int]
+	ref := &model{vals: map[int]int{}}
+
+	var snapshots []omap.Persistent[int, int]
+	var snapshotPairs [][]pair
+
+	var log []string
+	check := func() {
+		t.Helper()
+		want := ref.pairs()
+		if diff := cmp.Diff(want, pairs(m)); diff != "" {
+			t.Fatalf("Map differs from model after %v (-want +got):\n%s", log, diff)
+		}
+		if diff := cmp.Diff(want, pairs(p)); diff != "" {
+			t.Fatalf("Persistent differs from model after %v (-want +got):\n%s", log, diff)
+		}
+		if m.Len() != len(want) || p.Len() != len(want) {
+			t.Fatalf("Len = %d, %d; want %d after %v", m.Len(), p.Len(), len(want), log)
+		}
+		var back []pair
+		for k, v := range m.Backward() {
+			back = append(back, pair{k, v})
+		}
+		slices.Reverse(back)
+		if len(want) > 0 && !slices.Equal(back, want) {
+			t.Fatalf("Backward = %v, want reversed %v after %v", back, want, log)
+		}
+	}
+
+	for range 300 {
+
+		k := r.IntN(16)
+		v := r.IntN(100)
+
+		switch op := r.IntN(10); op {
+
+		case 0, 1, 2:
+			log = append(log, fmt.Sprintf("Set(%d, %d)", k, v))
+			m.Set(k, v)
+			p = p.With(k, v)
+			ref.set(k, v)
+
+		case 3, 4:
+			log = append(log, fmt.Sprintf("Delete(%d)", k))
+			got, gotOK := m.Delete(k)
+			want, wantOK := ref.delete(k)
+			if got != want || gotOK != wantOK {
+				t.Fatalf("Delete(%d) = %d, %v; want %d, %v after %v", k, got, gotOK, want, wantOK, log)
+			}
+			p = p.Without(k)
+
+		case 5:
+			log = append(log, fmt.Sprintf("Get(%d)", k))
+			got, gotOK := m.Get(k)
+			want, wantOK := ref.vals[k]
+			if got != want || gotOK != wantOK {
+				t.Fatalf("Get(%d) = %d, %v; want %d, %v after %v", k, got, gotOK, want, wantOK, log)
+			}
+			got, gotOK = p.Get(k)
+			if got != want || gotOK != wantOK {
+				t.Fatalf("Persistent.Get(%d) = %d, %v; want %d, %v after %v", k, got, gotOK, want, wantOK, log)
+			}
+
+		case 6:
+			log = append(log, fmt.Sprintf("DeleteFunc(v %% %d == 0)", k+1))
+			m.DeleteFunc(func(_, v int) bool { return v%(k+1) == 0 })
+			for _, x := range slices.Clone(ref.keys) {
+				if ref.vals[x]%(k+1) == 0 {
+					ref.delete(x)
+					p = p.Without(x)
+				}
+			}
+
+		case 7:
+			log = append(log, fmt.Sprintf("Entries().Delete(key > %d)", k))
+			for e := range m.Entries() {
+				if e.Key() > k {
+					e.Delete()
+				} else {
+					e.SetValue(e.Value() + 1)
+				}
+			}
+			for _, x := range slices.Clone(ref.keys) {
+				if x > k {
+					ref.delete(x)
+					p = p.Without(x)
+				} else {
+					ref.vals[x]++
+					p = p.With(x, ref.vals[x])
+				}
+			}
+
+		case 8:
+			log = append(log, "Clone")
+			c := m.Clone()
+			c.Set(k, v)
+			c.Delete(r.IntN(16))
+
+		case 9:
+			log = append(log, "JSON")
+			b, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m = omap.Map[int, int]{}
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatal(err)
+			}
+			snapshots = append(snapshots, p)
+			snapshotPairs = append(snapshotPairs, ref.pairs())
+		}
+
+		check()
+	}
+
+	for i, s := range snapshots {
+		if diff := cmp.Diff(snapshotPairs[i], pairs(s)); diff != "" {
+			t.Fatalf("Persistent snapshot %d changed (-want +got):\n%s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MergePatch returns the result of applying
+// the RFC 7396 JSON merge patch to doc.
+// doc is not modified.
+//
+// Keys that already exist in doc keep their position,
+// and new keys are appended in the order they appear in patch.
+func MergePatch(doc, patch Value) Value {
+	doc = doc.clone()
+	doc.V = mergePatch(doc.V, patch.V)
+	return doc
+}
+
+// mergePatch applies patch to doc in place where possible
+// and returns the result.
+func mergePatch(doc, patch any) any {
+
+	p, ok := patch.(Map[string, any])
+	if !ok {
+		return cloneValue(patch)
+	}
+
+	d, ok := doc.(Map[string, any])
+	if !ok {
+		d = New[string, any]()
+	}
+
+	for _, t := range p.s {
+		if t.val == nil {
+			if i := d.index(t.key); i != -1 {
+				d.s = slices.Delete(d.s, i, i+1)
+			}
+			continue
+		}
+		cur, _ := d.Get(t.key)
+		d.Set(t.key, mergePatch(cur, t.val))
+	}
+
+	return d
+}
+
+// Operation is a single RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value Value  `json:"value"`
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	m := New[string, any](4)
+	m.Set("op", o.Op)
+	m.Set("path", o.Path)
+	switch o.Op {
+	case "add", "replace", "test":
+		m.Set("value", o.Value.V)
+	case "move", "copy":
+		m.Set("from", o.From)
+	}
+	return Value{V: m}.MarshalJSON()
+}
+
+// UnmarshalJSON decodes an operation, failing if it's an add,
+// replace or test operation without a value member,
+// which RFC 6902 requires even if the value is null.
+func (o *Operation) UnmarshalJSON(b []byte) error {
+
+	type operation Operation
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(b, &members); err != nil {
+		return err
+	}
+
+	var x operation
+	if err := json.Unmarshal(b, &x); err != nil {
+		return err
+	}
+
+	if _, ok := members["value"]; !ok {
+		switch x.Op {
+		case "add", "replace", "test":
+			return fmt.Errorf("%s operation %q has no value", x.Op, x.Path)
+		}
+	}
+
+	*o = Operation(x)
+	return nil
+}
+
+// Patch is an RFC 6902 JSON Patch.
+type Patch []Operation
+
+// Apply returns the result of applying the patch to doc.
+// doc is not modified, and nothing is returned
+// if any of the operations fails.
+//
+// Adding an existing object member replaces its value in place,
+// while new members are appended to the object.
+func (p Patch) Apply(doc Value) (Value, error) {
+
+	v := doc.clone()
+
+	for i, op := range p {
+		var err error
+		v.V, err = op.apply(v.V)
+		if err != nil {
+			return Value{}, fmt.Errorf(
+				"patch operation %d (%s %q): %w",
+				i, op.Op, op.Path, err,
+			)
+		}
+	}
+
+	return v, nil
+}
+
+var errPathNotFound = errors.New("path not found")
+
+func (o Operation) apply(doc any) (any, error) {
+
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+
+	case "add":
+		return patchAdd(doc, path, cloneValue(o.Value.V))
+
+	case "remove":
+		if len(path) == 0 {
+			return nil, fmt.Errorf("cannot remove the whole document")
+		}
+		return patchUpdate(doc, path, patchRemove)
+
+	case "replace":
+		if len(path) == 0 {
+			return cloneValue(o.Value.V), nil
+		}
+		val := cloneValue(o.Value.V)
+		return patchUpdate(doc, path, func(c any, key string) (any, error) {
+			switch c := c.(type) {
+			case Map[string, any]:
+				if c.index(key) == -1 {
+					return nil, errPathNotFound
+				}
+				c.Set(key, val)
+				return c, nil
+			case []any:
+				i, ok := arrayIndex(key, len(c))
+				if !ok {
+					return nil, errPathNotFound
+				}
+				c[i] = val
+				return c, nil
+			default:
+				return nil, errPathNotFound
+			}
+		})
+
+	case "move":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, fmt.Errorf("cannot move %q into its own child", o.From)
+		}
+		val, ok := Value{V: doc}.Lookup(from...)
+		if !ok {
+			return nil, errPathNotFound
+		}
+		if len(from) > 0 {
+			if doc, err = patchUpdate(doc, from, patchRemove); err != nil {
+				return nil, err
+			}
+		}
+		return patchAdd(doc, path, val.V)
+
+	case "copy":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := Value{V: doc}.Lookup(from...)
+		if !ok {
+			return nil, errPathNotFound
+		}
+		return patchAdd(doc, path, cloneValue(val.V))
+
+	case "test":
+		val, ok := Value{V: doc}.Lookup(path...)
+		if !ok {
+			return nil, errPathNotFound
+		}
+		if !equalValues(val.V, o.Value.V) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("unknown operation %q", o.Op)
+	}
+}
+
+func patchAdd(doc any, path []string, val any) (any, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+	return patchUpdate(doc, path, func(c any, key string) (any, error) {
+		switch c := c.(type) {
+		case Map[string, any]:
+			c.Set(key, val)
+			return c, nil
+		case []any:
+			if key == "-" {
+				return append(c, val), nil
+			}
+			i, ok := arrayIndex(key, len(c)+1)
+			if !ok {
+				return nil, errPathNotFound
+			}
+			return slices.Insert(c, i, val), nil
+		default:
+			return nil, errPathNotFound
+		}
+	})
+}
+
+func patchRemove(c any, key string) (any, error) {
+	switch c := c.(type) {
+	case Map[string, any]:
+		i := c.index(key)
+		if i == -1 {
+			return nil, errPathNotFound
+		}
+		c.s = slices.Delete(c.s, i, i+1)
+		return c, nil
+	case []any:
+		i, ok := arrayIndex(key, len(c))
+		if !ok {
+			return nil, errPathNotFound
+		}
+		return slices.Delete(c, i, i+1), nil
+	default:
+		return nil, errPathNotFound
+	}
+}
+
+// patchUpdate calls fn with the container at path[:len(path)-1]
+// and the last element of path,
+// and returns doc with the container replaced by the result of fn.
+// path must not be empty.
+func patchUpdate(doc any, path []string, fn func(c any, key string) (any, error)) (any, error) {
+
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	child, ok := Value{V: doc}.child(path[0])
+	if !ok {
+		return nil, errPathNotFound
+	}
+
+	x, err := patchUpdate(child.V, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := doc.(type) {
+	case Map[string, any]:
+		c.Set(path[0], x)
+	case []any:
+		i, _ := arrayIndex(path[0], len(c))
+		c[i] = x
+	}
+
+	return doc, nil
+}
+
+// Diff returns a patch that turns a into b.
+//
+// Objects are compared member by member; other values,
+// including arrays, are replaced as a whole if they differ.
+// New members are added in the order they appear in b,
+// but reordering of members that exist in both a and b
+// is not captured.
+func Diff(a, b Value) Patch {
+	return diff(nil, "", a.V, b.V)
+}
+
+func diff(p Patch, path string, a, b any) Patch {
+
+	am, aok := a.(Map[string, any])
+	bm, bok := b.(Map[string, any])
+
+	if !aok || !bok {
+		if !equalValues(a, b) {
+			p = append(p, Operation{Op: "replace", Path: path, Value: Value{V: cloneValue(b)}})
+		}
+		return p
+	}
+
+	for _, t := range am.s {
+		if bm.index(t.key) == -1 {
+			p = append(p, Operation{Op: "remove", Path: path + "/" + escapePointer(t.key)})
+		}
+	}
+
+	for _, t := range bm.s {
+		child := path + "/" + escapePointer(t.key)
+		if av, ok := am.Get(t.key); ok {
+			p = diff(p, child, av, t.val)
+		} else {
+			p = append(p, Operation{Op: "add", Path: child, Value: Value{V: cloneValue(t.val)}})
+		}
+	}
+
+	return p
+}
+
+func escapePointer(s string) string {
+	s = strings.ReplaceAll(s, "~", "~0")
+	return strings.ReplaceAll(s, "/", "~1")
+}
+
+// cloneValue returns a deep copy of the objects and arrays in v.
+func cloneValue(v any) any {
+	return cloneSource(v, nil, nil)
+}
+
+// cloneSource is like cloneValue, but also copies the texts
+// recorded in src for the strings of v to dst, if src isn't nil.
+func cloneSource(v any, src, dst *valueSource) any {
+	switch v := v.(type) {
+	case Map[string, any]:
+		if v.IsNil() {
+			return v
+		}
+		m := New[string, any](len(v.s))
+		for _, t := range v.s {
+			m.s = append(m.s, tuple[string, any]{t.key, cloneSource(t.val, src, dst)})
+		}
+		if src != nil {
+			// The texts are checked against the strings
+			// when encoding, so they can be shared.
+			if keys := src.keys[v.omap]; keys != nil {
+				dst.keys[m.omap] = keys
+			}
+			if vals := src.vals[v.omap]; vals != nil {
+				dst.vals[m.omap] = vals
+			}
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, x := range v {
+			a[i] = cloneSource(x, src, dst)
+			if src != nil {
+				if raw, ok := src.elems[&v[i]]; ok {
+					dst.elems[&a[i]] = raw
+				}
+			}
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+// equalValues reports whether a and b are equal JSON values.
+// Numbers are compared by value, whether they're [json.Number]s
+// or Go numbers, and objects regardless of key order.
+func equalValues(a, b any) bool {
+
+	switch a := a.(type) {
+
+	case Map[string, any]:
+		b, ok := b.(Map[string, any])
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, t := range a.s {
+			x, ok := b.Get(t.key)
+			if !ok || !equalValues(t.val, x) {
+				return false
+			}
+		}
+		return true
+
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalValues(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		if x, ok := numberValue(a); ok {
+			y, ok := numberValue(b)
+			return ok && x.Cmp(y) == 0
+		}
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// numberValue returns the exact value of v
+// if it's a [json.Number] or a finite Go number.
+func numberValue(v any) (*big.Rat, bool) {
+
+	r := new(big.Rat)
+
+	if n, ok := v.(json.Number); ok {
+		_, ok := r.SetString(string(n))
+		return r, ok
+	}
+
+	x := reflect.ValueOf(v)
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.SetInt64(x.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return r.SetInt(new(big.Int).SetUint64(x.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		f := x.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, false
+		}
+		return r.SetFloat64(f), true
+	default:
+		return nil, false
+	}
+}
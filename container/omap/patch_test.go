@@ -0,0 +1,339 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func mustValue(t *testing.T, s string) omap.Value {
+	t.Helper()
+	var v omap.Value
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	return v
+}
+
+func valueJSON(t *testing.T, v omap.Value) string {
+	t.Helper()
+	b, err := v.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMergePatch(t *testing.T) {
+
+	// Examples from RFC 7396, Appendix A,
+	// whose results keep the order of the keys.
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+
+		// Existing keys keep their position.
+		{`{"z":1,"y":2,"x":3}`, `{"a":0,"y":20,"z":null}`, `{"y":20,"x":3,"a":0}`},
+	}
+
+	for _, tt := range tests {
+		doc := mustValue(t, tt.doc)
+		got := valueJSON(t, omap.MergePatch(doc, mustValue(t, tt.patch)))
+		if got != tt.want {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+		if valueJSON(t, doc) != tt.doc {
+			t.Errorf("MergePatch(%s, %s) modified the document", tt.doc, tt.patch)
+		}
+	}
+}
+
+func TestPatch(t *testing.T) {
+
+	// Examples from RFC 6902, Appendix A,
+	// followed by other cases.
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{
+			"add object member",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			"add array element",
+			`{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			"remove object member",
+			`{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`,
+			`{"foo":"bar"}`,
+		},
+		{
+			"remove array element",
+			`{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`,
+		},
+		{
+			"replace value",
+			`{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			"move value",
+			`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			"move array element",
+			`{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			"test value",
+			`{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			"test value error",
+			`{"baz":"qux"}`,
+			`[{"op":"test","path":"/baz","value":"bar"}]`,
+			"",
+		},
+		{
+			"add nested member",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			"ignore unrecognized elements",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			"add to nonexistent target",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			"",
+		},
+		{
+			"escape ordering",
+			`{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`,
+			`{"/":9,"~1":10}`,
+		},
+		{
+			"compare strings and numbers",
+			`{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":"10"}]`,
+			"",
+		},
+		{
+			"add array value",
+			`{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			"add existing member in place",
+			`{"a":1,"b":2}`,
+			`[{"op":"add","path":"/a","value":3}]`,
+			`{"a":3,"b":2}`,
+		},
+		{
+			"move into own child",
+			`{"a":{"b":1}}`,
+			`[{"op":"move","from":"/a","path":"/a/c"}]`,
+			"",
+		},
+		{
+			"move to itself",
+			`{"a":{"b":1},"c":2}`,
+			`[{"op":"move","from":"/a","path":"/a"}]`,
+			`{"c":2,"a":{"b":1}}`,
+		},
+		{
+			"copy",
+			`{"a":{"b":1}}`,
+			`[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			`{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			"test normalizes numbers",
+			`{"n":1.0,"m":[100]}`,
+			`[{"op":"test","path":"/n","value":1},{"op":"test","path":"/m","value":[1e2]}]`,
+			`{"n":1.0,"m":[100]}`,
+		},
+		{
+			"test objects regardless of order",
+			`{"o":{"a":1,"b":2}}`,
+			`[{"op":"test","path":"/o","value":{"b":2,"a":1}}]`,
+			`{"o":{"a":1,"b":2}}`,
+		},
+		{
+			"failed patch changes nothing",
+			`{"a":1}`,
+			`[{"op":"remove","path":"/a"},{"op":"remove","path":"/a"}]`,
+			"",
+		},
+		{
+			"unknown operation",
+			`{"a":1}`,
+			`[{"op":"frob","path":"/a"}]`,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+
+		var p omap.Patch
+		if err := json.Unmarshal([]byte(tt.patch), &p); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		doc := mustValue(t, tt.doc)
+		got, err := p.Apply(doc)
+
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: Apply succeeded, want error", tt.name)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if s := valueJSON(t, got); s != tt.want {
+			t.Errorf("%s: Apply = %s, want %s", tt.name, s, tt.want)
+		}
+
+		if valueJSON(t, doc) != tt.doc {
+			t.Errorf("%s: Apply modified the document", tt.name)
+		}
+	}
+}
+
+func TestPatchNumbers(t *testing.T) {
+
+	m := omap.New[string, any]()
+	m.Set("a", json.Number("1"))
+	m.Set("b", 1.0)
+	m.Set("c", int64(1))
+	m.Set("d", uint8(1))
+	doc := omap.Value{V: m}
+
+	for _, path := range []string{"/a", "/b", "/c", "/d"} {
+		for _, v := range []any{json.Number("1.0"), json.Number("1e0"), 1.0, float32(1), 1, int64(1), uint(1)} {
+			p := omap.Patch{{Op: "test", Path: path, Value: omap.Value{V: v}}}
+			if _, err := p.Apply(doc); err != nil {
+				t.Errorf("test %s against %T(%v): %v", path, v, v, err)
+			}
+		}
+		for _, v := range []any{json.Number("2"), 1.5, int64(2), "1", true} {
+			p := omap.Patch{{Op: "test", Path: path, Value: omap.Value{V: v}}}
+			if _, err := p.Apply(doc); err == nil {
+				t.Errorf("test %s against %T(%v) succeeded, want error", path, v, v)
+			}
+		}
+	}
+}
+
+func TestPatchMissingValue(t *testing.T) {
+
+	for _, op := range []string{"add", "replace", "test"} {
+		var p omap.Patch
+		s := `[{"op":"` + op + `","path":"/a"}]`
+		if err := json.Unmarshal([]byte(s), &p); err == nil {
+			t.Errorf("%s: Unmarshal succeeded, want error", s)
+		}
+	}
+
+	// A null value is still a value.
+	var p omap.Patch
+	s := `[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/b"}]`
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	got, err := p.Apply(mustValue(t, `{"b":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := valueJSON(t, got); s != `{"a":null}` {
+		t.Errorf("Apply = %s, want %s", s, `{"a":null}`)
+	}
+}
+
+func TestDiff(t *testing.T) {
+
+	tests := []struct {
+		a, b string
+	}{
+		{`{"a":1,"b":{"c":2,"d":3}}`, `{"a":1,"b":{"c":4},"e":[5]}`},
+		{`{"a":1}`, `{}`},
+		{`{"a/b":{"~":1}}`, `{"a/b":{"~":2,"x":null}}`},
+		{`{"a":[1,2]}`, `{"a":[1,2,3]}`},
+		{`{"n":1.0}`, `{"n":1}`},
+		{`[1]`, `{"a":1}`},
+		{`null`, `"s"`},
+	}
+
+	for _, tt := range tests {
+
+		a, b := mustValue(t, tt.a), mustValue(t, tt.b)
+		p := omap.Diff(a, b)
+
+		got, err := p.Apply(a)
+		if err != nil {
+			t.Fatalf("Diff(%s, %s): %v", tt.a, tt.b, err)
+		}
+		if len(omap.Diff(got, b)) != 0 {
+			t.Errorf("Apply(%s, Diff(%s, %s)) = %s", tt.a, tt.a, tt.b, valueJSON(t, got))
+		}
+	}
+
+	// Equal values have an empty diff, numbers being compared by value.
+	a := mustValue(t, `{"n":1.0,"s":[1,{"x":"y"}]}`)
+	b := mustValue(t, `{"n":1,"s":[1,{"x":"y"}]}`)
+	if p := omap.Diff(a, b); len(p) != 0 {
+		t.Errorf("Diff of equal values = %v, want empty", p)
+	}
+
+	// The patch encodes to JSON, with its members in order.
+	p := omap.Diff(mustValue(t, `{"a":1,"b":2}`), mustValue(t, `{"b":3,"c":4}`))
+	got, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"remove","path":"/a"},{"op":"replace","path":"/b","value":3},{"op":"add","path":"/c","value":4}]`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("json.Marshal of diff: incorrect result (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,500 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"fmt"
+	"hash/maphash"
+	"io"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Persistent is an immutable ordered map.
+//
+// [Persistent.With] and [Persistent.Without] return new versions
+// of the map in O(log n), sharing most of their structure
+// with the original, which stays unchanged.
+// Persistent values are therefore safe for concurrent use,
+// and can be handed out as snapshots without copying.
+//
+// Persistent has the iterators and encodings of [Map];
+// for the rest of its API, use [Persistent.Thaw].
+//
+// The zero value is an empty map.
+type Persistent[K comparable, V any] struct {
+	// order holds the entries keyed by their insertion sequence number,
+	// and index holds the sequence numbers keyed by the hash of the keys.
+	order *pnode[tuple[K, V]]
+	index *pnode[[]pentry[K]]
+	next  uint64
+	n     int
+}
+
+type pentry[K comparable] struct {
+	key K
+	seq uint64
+}
+
+var persistentSeed = maphash.MakeSeed()
+
+func (p Persistent[K, V]) Get(key K) (val V, has bool) {
+	seq, ok := p.seq(key)
+	if !ok {
+		return val, false
+	}
+	t, _ := p.order.get(seq)
+	return t.val, true
+}
+
+func (p Persistent[K, V]) Len() int {
+	return p.n
+}
+
+// With returns a copy of the map with key set to val.
+// An existing key keeps its position, and a new key is appended.
+func (p Persistent[K, V]) With(key K, val V) Persistent[K, V] {
+
+	t := tuple[K, V]{key: key, val: val}
+
+	if seq, ok := p.seq(key); ok {
+		p.order = p.order.put(seq, t)
+		return p
+	}
+
+	h := maphash.Comparable(persistentSeed, key)
+	bucket, _ := p.index.get(h)
+	bucket = append(slices.Clip(bucket), pentry[K]{key, p.next})
+
+	p.order = p.order.put(p.next, t)
+	p.index = p.index.put(h, bucket)
+	p.next++
+	p.n++
+
+	return p
+}
+
+// Without returns a copy of the map without key.
+func (p Persistent[K, V]) Without(key K) Persistent[K, V] {
+
+	h := maphash.Comparable(persistentSeed, key)
+	bucket, _ := p.index.get(h)
+
+	i := slices.IndexFunc(bucket, func(e pentry[K]) bool {
+		return e.key == key
+	})
+	if i == -1 {
+		return p
+	}
+
+	p.order = p.order.delete(bucket[i].seq)
+	if len(bucket) == 1 {
+		p.index = p.index.delete(h)
+	} else {
+		p.index = p.index.put(h, slices.Delete(slices.Clone(bucket), i, i+1))
+	}
+	p.n--
+
+	return p
+}
+
+func (p Persistent[K, V]) seq(key K) (uint64, bool) {
+	bucket, _ := p.index.get(maphash.Comparable(persistentSeed, key))
+	for _, e := range bucket {
+		if e.key == key {
+			return e.seq, true
+		}
+	}
+	return 0, false
+}
+
+func (p Persistent[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		p.order.walk(false, func(t tuple[K, V]) bool {
+			return yield(t.key, t.val)
+		})
+	}
+}
+
+func (p Persistent[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		p.order.walk(true, func(t tuple[K, V]) bool {
+			return yield(t.key, t.val)
+		})
+	}
+}
+
+func (p Persistent[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		p.order.walk(false, func(t tuple[K, V]) bool {
+			return yield(t.key)
+		})
+	}
+}
+
+func (p Persistent[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		p.order.walk(false, func(t tuple[K, V]) bool {
+			return yield(t.val)
+		})
+	}
+}
+
+// From is like [Map.From].
+func (p Persistent[K, V]) From(key K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		seq, ok := p.seq(key)
+		if !ok {
+			return
+		}
+		p.order.walkRange(seq, math.MaxUint64, false, func(t tuple[K, V]) bool {
+			return yield(t.key, t.val)
+		})
+	}
+}
+
+// FromIndex is like [Map.FromIndex].
+// It takes O(i) time to reach the i'th pair.
+func (p Persistent[K, V]) FromIndex(i int) iter.Seq2[K, V] {
+	if i < 0 {
+		panic("omap.Persistent.FromIndex: i < 0")
+	}
+	return func(yield func(K, V) bool) {
+		n := 0
+		p.order.walk(false, func(t tuple[K, V]) bool {
+			n++
+			return n <= i || yield(t.key, t.val)
+		})
+	}
+}
+
+// Between is like [Map.Between].
+func (p Persistent[K, V]) Between(startKey, endKey K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		i, ok1 := p.seq(startKey)
+		j, ok2 := p.seq(endKey)
+		if !ok1 || !ok2 {
+			return
+		}
+		fn := func(t tuple[K, V]) bool {
+			return yield(t.key, t.val)
+		}
+		if i <= j {
+			p.order.walkRange(i, j, false, fn)
+		} else {
+			p.order.walkRange(j, i, true, fn)
+		}
+	}
+}
+
+// Freeze returns a persistent copy of the map.
+func (m Map[K, V]) Freeze() Persistent[K, V] {
+	return Persistent[K, V]{}.withAll(m)
+}
+
+// Thaw returns a mutable copy of the map.
+func (p Persistent[K, V]) Thaw() Map[K, V] {
+	m := New[K, V](p.n)
+	m.s = p.tuples()
+	return m
+}
+
+// withAll returns a copy of p with the key-value pairs of m set in it.
+func (p Persistent[K, V]) withAll(m Map[K, V]) Persistent[K, V] {
+	for k, v := range m.All() {
+		p = p.With(k, v)
+	}
+	return p
+}
+
+func (p Persistent[K, V]) tuples() []tuple[K, V] {
+	s := make([]tuple[K, V], 0, p.n)
+	p.order.walk(false, func(t tuple[K, V]) bool {
+		s = append(s, t)
+		return true
+	})
+	return s
+}
+
+func (p Persistent[K, V]) String() string {
+	var sb strings.Builder
+	sb.WriteString("omap.Persistent[")
+	f := "%v:%v"
+	for i, t := range p.tuples() {
+		if i == 1 {
+			f = " " + f
+		}
+		fmt.Fprintf(&sb, f, t.key, t.val)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func (p Persistent[K, V]) MarshalJSON() ([]byte, error) {
+	return marshalJSONTuples(p.tuples())
+}
+
+// UnmarshalJSON sets the key-value pairs of a JSON object in p.
+// Like the rest of the Persistent methods, it doesn't modify
+// the versions of the map that p held before.
+func (p *Persistent[K, V]) UnmarshalJSON(b []byte) error {
+	q := *p
+	err := decodeJSONObject(b, func(key K, val V, _ int64, _ string) error {
+		q = q.With(key, val)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
+
+// MarshalYAML encodes the map as a YAML mapping node.
+func (p Persistent[K, V]) MarshalYAML() (any, error) {
+	return p.Thaw().MarshalYAML()
+}
+
+// UnmarshalYAML sets the key-value pairs of a YAML mapping node in p.
+// See [Persistent.UnmarshalJSON].
+func (p *Persistent[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	q := *p
+	err := decodeYAMLMapping(node, func(key K, val V, _, _ *yaml.Node) error {
+		q = q.With(key, val)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
+
+// MarshalTOML encodes the map as a TOML document.
+// See [Map.MarshalTOML].
+func (p Persistent[K, V]) MarshalTOML() ([]byte, error) {
+	return p.Thaw().MarshalTOML()
+}
+
+// UnmarshalTOML sets the key-value pairs of the TOML document b in p.
+// See [Map.UnmarshalTOML] and [Persistent.UnmarshalJSON].
+func (p *Persistent[K, V]) UnmarshalTOML(b []byte) error {
+	var m Map[K, V]
+	if err := m.UnmarshalTOML(b); err != nil {
+		return err
+	}
+	*p = p.withAll(m)
+	return nil
+}
+
+// GobEncode implements [gob.GobEncoder]. See [Map.GobEncode].
+func (p Persistent[K, V]) GobEncode() ([]byte, error) {
+	return p.Thaw().GobEncode()
+}
+
+// GobDecode implements [gob.GobDecoder].
+// See [Persistent.UnmarshalJSON].
+func (p *Persistent[K, V]) GobDecode(b []byte) error {
+	var m Map[K, V]
+	if err := m.GobDecode(b); err != nil {
+		return err
+	}
+	*p = p.withAll(m)
+	return nil
+}
+
+// EncodeMsgpack writes the map to w as a MessagePack map.
+// See [Map.EncodeMsgpack].
+func (p Persistent[K, V]) EncodeMsgpack(w io.Writer) error {
+	return p.Thaw().EncodeMsgpack(w)
+}
+
+// DecodeMsgpack reads a MessagePack map from r and sets its pairs in p.
+// See [Map.DecodeMsgpack] and [Persistent.UnmarshalJSON].
+func (p *Persistent[K, V]) DecodeMsgpack(r io.Reader) error {
+	var m Map[K, V]
+	if err := m.DecodeMsgpack(r); err != nil {
+		return err
+	}
+	*p = p.withAll(m)
+	return nil
+}
+
+// EncodeCBOR writes the map to w as a CBOR map.
+// See [Map.EncodeCBOR].
+func (p Persistent[K, V]) EncodeCBOR(w io.Writer) error {
+	return p.Thaw().EncodeCBOR(w)
+}
+
+// DecodeCBOR reads a CBOR map from r and sets its pairs in p.
+// See [Map.DecodeCBOR] and [Persistent.UnmarshalJSON].
+func (p *Persistent[K, V]) DecodeCBOR(r io.Reader) error {
+	var m Map[K, V]
+	if err := m.DecodeCBOR(r); err != nil {
+		return err
+	}
+	*p = p.withAll(m)
+	return nil
+}
+
+// pnode is a node of a persistent AVL tree keyed by integers.
+// Nodes are never modified once created;
+// updates copy the path from the root to the updated node.
+type pnode[T any] struct {
+	key         uint64
+	val         T
+	left, right *pnode[T]
+	height      int8
+}
+
+func (n *pnode[T]) get(key uint64) (val T, has bool) {
+	for n != nil {
+		switch {
+		case key < n.key:
+			n = n.left
+		case key > n.key:
+			n = n.right
+		default:
+			return n.val, true
+		}
+	}
+	return val, false
+}
+
+// put returns the tree with key set to val.
+func (n *pnode[T]) put(key uint64, val T) *pnode[T] {
+	if n == nil {
+		return &pnode[T]{key: key, val: val, height: 1}
+	}
+	c := *n
+	switch {
+	case key < n.key:
+		c.left = n.left.put(key, val)
+	case key > n.key:
+		c.right = n.right.put(key, val)
+	default:
+		c.val = val
+		return &c
+	}
+	return c.balance()
+}
+
+// delete returns the tree without key.
+func (n *pnode[T]) delete(key uint64) *pnode[T] {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	switch {
+	case key < n.key:
+		c.left = n.left.delete(key)
+	case key > n.key:
+		c.right = n.right.delete(key)
+	default:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		succ := n.right
+		for succ.left != nil {
+			succ = succ.left
+		}
+		c.key, c.val = succ.key, succ.val
+		c.right = n.right.delete(succ.key)
+	}
+	return c.balance()
+}
+
+// balance restores the AVL invariant of n, whose subtrees
+// differ in height by at most 2, and returns the new root.
+// n must be a fresh copy, as it may be modified.
+func (n *pnode[T]) balance() *pnode[T] {
+	switch n.left.h() - n.right.h() {
+	case 2:
+		if n.left.left.h() < n.left.right.h() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case -2:
+		if n.right.right.h() < n.right.left.h() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	default:
+		n.fix()
+		return n
+	}
+}
+
+func (n *pnode[T]) rotateLeft() *pnode[T] {
+	r := *n.right
+	c := *n
+	c.right = r.left
+	c.fix()
+	r.left = &c
+	r.fix()
+	return &r
+}
+
+func (n *pnode[T]) rotateRight() *pnode[T] {
+	l := *n.left
+	c := *n
+	c.left = l.right
+	c.fix()
+	l.right = &c
+	l.fix()
+	return &l
+}
+
+func (n *pnode[T]) h() int8 {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *pnode[T]) fix() {
+	n.height = max(n.left.h(), n.right.h()) + 1
+}
+
+// walk calls fn for the values of the tree in key order,
+// or in reverse order if backward is set,
+// until fn returns false.
+func (n *pnode[T]) walk(backward bool, fn func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	first, last := n.left, n.right
+	if backward {
+		first, last = last, first
+	}
+	return first.walk(backward, fn) && fn(n.val) && last.walk(backward, fn)
+}
+
+// walkRange is like walk, but only visits the keys from lo to hi
+// (both inclusive).
+func (n *pnode[T]) walkRange(lo, hi uint64, backward bool, fn func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.key < lo {
+		return n.right.walkRange(lo, hi, backward, fn)
+	}
+	if n.key > hi {
+		return n.left.walkRange(lo, hi, backward, fn)
+	}
+	first, last := n.left, n.right
+	if backward {
+		first, last = last, first
+	}
+	return first.walkRange(lo, hi, backward, fn) &&
+		fn(n.val) &&
+		last.walkRange(lo, hi, backward, fn)
+}
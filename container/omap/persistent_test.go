@@ -0,0 +1,211 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"iter"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+	"go.yaml.in/yaml/v4"
+)
+
+func collect(seq iter.Seq2[int, int]) []pair {
+	p := []pair{}
+	for k, v := range seq {
+		p = append(p, pair{k, v})
+	}
+	return p
+}
+
+func TestPersistentIterators(t *testing.T) {
+
+	// Deletions leave gaps in the sequence numbers of the entries,
+	// and re-added keys move to the end.
+	m := omap.New[int, int]()
+	for k := range 40 {
+		m.Set(k, k*10)
+	}
+	for k := 0; k < 40; k += 3 {
+		m.Delete(k)
+	}
+	for k := 0; k < 40; k += 9 {
+		m.Set(k, -k)
+	}
+	p := m.Freeze()
+
+	check := func(title string, want, got []pair) {
+		t.Helper()
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("%s: incorrect result (-want +got):\n%s", title, diff)
+		}
+	}
+
+	check("All", collect(m.All()), collect(p.All()))
+	check("Backward", collect(m.Backward()), collect(p.Backward()))
+	if diff := cmp.Diff(slices.Collect(m.Keys()), slices.Collect(p.Keys())); diff != "" {
+		t.Errorf("Keys: incorrect result (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(slices.Collect(m.Values()), slices.Collect(p.Values())); diff != "" {
+		t.Errorf("Values: incorrect result (-want +got):\n%s", diff)
+	}
+
+	for k := -1; k <= 41; k++ {
+		check("From", collect(m.From(k)), collect(p.From(k)))
+	}
+	for i := range m.Len() + 2 {
+		check("FromIndex", collect(m.FromIndex(i)), collect(p.FromIndex(i)))
+	}
+	for a := -1; a <= 41; a++ {
+		for b := -1; b <= 41; b++ {
+			check("Between", collect(m.Between(a, b)), collect(p.Between(a, b)))
+		}
+	}
+
+	// Stopping early.
+	first := func(seq iter.Seq2[int, int]) []pair {
+		for k, v := range seq {
+			return []pair{{k, v}}
+		}
+		return nil
+	}
+	check("From, first", first(m.From(5)), first(p.From(5)))
+	check("FromIndex, first", first(m.FromIndex(5)), first(p.FromIndex(5)))
+	check("Between, first", first(m.Between(5, 30)), first(p.Between(5, 30)))
+	check("Between backward, first", first(m.Between(30, 5)), first(p.Between(30, 5)))
+
+	var empty omap.Persistent[int, int]
+	check("empty From", []pair{}, collect(empty.From(0)))
+	check("empty FromIndex", []pair{}, collect(empty.FromIndex(0)))
+	check("empty Between", []pair{}, collect(empty.Between(0, 0)))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("FromIndex(-1) didn't panic")
+		}
+	}()
+	p.FromIndex(-1)
+}
+
+func TestPersistentThaw(t *testing.T) {
+
+	m := mapOf(3, 30, 1, 10, 2, 20)
+	p := m.Freeze()
+
+	m.Set(4, 40)
+	if p.Len() != 3 {
+		t.Error("Freeze shares its entries with the map")
+	}
+
+	c := p.Thaw()
+	c.Set(1, 11)
+	if v, _ := p.Get(1); v != 10 {
+		t.Error("Thaw shares its entries with the persistent map")
+	}
+
+	if got, want := p.String(), "omap.Persistent[3:30 1:10 2:20]"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestPersistentEncoding(t *testing.T) {
+
+	p := omap.New[string, int]()
+	p.Set("z", 1)
+	p.Set("a", 2)
+	p.Set("m", 3)
+	want := p.Freeze()
+
+	formats := []struct {
+		name   string
+		encode func(omap.Persistent[string, int]) ([]byte, error)
+		decode func([]byte, *omap.Persistent[string, int]) error
+	}{
+		{
+			"json",
+			func(p omap.Persistent[string, int]) ([]byte, error) { return json.Marshal(p) },
+			func(b []byte, p *omap.Persistent[string, int]) error { return json.Unmarshal(b, p) },
+		},
+		{
+			"yaml",
+			func(p omap.Persistent[string, int]) ([]byte, error) { return yaml.Marshal(p) },
+			func(b []byte, p *omap.Persistent[string, int]) error { return yaml.Unmarshal(b, p) },
+		},
+		{
+			"toml",
+			omap.Persistent[string, int].MarshalTOML,
+			func(b []byte, p *omap.Persistent[string, int]) error { return p.UnmarshalTOML(b) },
+		},
+		{
+			"gob",
+			func(p omap.Persistent[string, int]) ([]byte, error) {
+				var b bytes.Buffer
+				err := gob.NewEncoder(&b).Encode(p)
+				return b.Bytes(), err
+			},
+			func(b []byte, p *omap.Persistent[string, int]) error {
+				return gob.NewDecoder(bytes.NewReader(b)).Decode(p)
+			},
+		},
+		{
+			"msgpack",
+			func(p omap.Persistent[string, int]) ([]byte, error) {
+				var b bytes.Buffer
+				err := p.EncodeMsgpack(&b)
+				return b.Bytes(), err
+			},
+			func(b []byte, p *omap.Persistent[string, int]) error {
+				return p.DecodeMsgpack(bytes.NewReader(b))
+			},
+		},
+		{
+			"cbor",
+			func(p omap.Persistent[string, int]) ([]byte, error) {
+				var b bytes.Buffer
+				err := p.EncodeCBOR(&b)
+				return b.Bytes(), err
+			},
+			func(b []byte, p *omap.Persistent[string, int]) error {
+				return p.DecodeCBOR(bytes.NewReader(b))
+			},
+		},
+	}
+
+	for _, f := range formats {
+
+		b, err := f.encode(want)
+		if err != nil {
+			t.Fatalf("%s: encoding: %v", f.name, err)
+		}
+
+		var got omap.Persistent[string, int]
+		if err := f.decode(b, &got); err != nil {
+			t.Fatalf("%s: decoding: %v", f.name, err)
+		}
+		if !omap.Equal(want.Thaw(), got.Thaw(), omap.OrderSensitive) {
+			t.Errorf("%s: round trip = %v, want %v", f.name, got, want)
+		}
+
+		// Decoding sets the pairs in a new version of the map.
+		old := omap.New[string, int]()
+		old.Set("a", 0)
+		old.Set("x", 0)
+		got = old.Freeze()
+		before := got
+		if err := f.decode(b, &got); err != nil {
+			t.Fatalf("%s: decoding: %v", f.name, err)
+		}
+		if s := got.String(); s != "omap.Persistent[a:2 x:0 z:1 m:3]" {
+			t.Errorf("%s: decoding into a map = %s", f.name, s)
+		}
+		if s := before.String(); s != "omap.Persistent[a:0 x:0]" {
+			t.Errorf("%s: decoding modified the previous version: %s", f.name, s)
+		}
+	}
+}
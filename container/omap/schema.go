@@ -0,0 +1,195 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Schema maps the keys of an object to the Go types of their values,
+// for decoding objects whose values differ in type from key to key.
+//
+// Keys are matched against the patterns of the schema
+// in the order they were registered, using the syntax of [path.Match].
+//
+// The zero value is an empty schema.
+type Schema struct {
+	fields []schemaField
+
+	// AllowUnknown makes the decoding keep the keys that match
+	// no pattern instead of reporting them, with their raw values:
+	// a [json.RawMessage] for JSON and a *[yaml.Node] for YAML.
+	AllowUnknown bool
+}
+
+type schemaField struct {
+	pattern string
+	typ     reflect.Type
+	nested  *Schema
+}
+
+// ErrUnknownKey is reported for keys that match no pattern of a schema.
+var ErrUnknownKey = errors.New("unknown key")
+
+// FieldError is an error decoding the value of a key.
+type FieldError struct {
+	// Path holds the keys leading to the value,
+	// from the top-level object down.
+	Path []string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	var sb strings.Builder
+	for _, key := range e.Path {
+		sb.WriteByte('/')
+		sb.WriteString(escapePointer(key))
+	}
+	return fmt.Sprintf("%s: %v", sb.String(), e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Register makes s decode the values of the keys matching pattern into T.
+// It panics if pattern is malformed.
+func Register[T any](s *Schema, pattern string) {
+	s.add(schemaField{pattern: pattern, typ: reflect.TypeFor[T]()})
+}
+
+// Nested makes s decode the values of the keys matching pattern,
+// which must be objects, into Map[string, any] according to nested.
+// It panics if pattern is malformed.
+func (s *Schema) Nested(pattern string, nested *Schema) {
+	s.add(schemaField{pattern: pattern, nested: nested})
+}
+
+func (s *Schema) add(f schemaField) {
+	if _, err := path.Match(f.pattern, ""); err != nil {
+		panic(fmt.Sprintf("omap: bad schema pattern %q", f.pattern))
+	}
+	s.fields = append(s.fields, f)
+}
+
+func (s *Schema) match(key string) (schemaField, bool) {
+	for _, f := range s.fields {
+		if ok, _ := path.Match(f.pattern, key); ok {
+			return f, true
+		}
+	}
+	return schemaField{}, false
+}
+
+// DecodeJSON decodes the JSON object b according to the schema.
+//
+// The errors of all the keys are reported at once,
+// joined with [errors.Join], as [*FieldError]s.
+// The returned map holds the keys that were decoded successfully.
+func (s *Schema) DecodeJSON(b []byte) (Map[string, any], error) {
+	var errs []error
+	m, err := s.decodeJSON(b, nil, &errs)
+	if err != nil {
+		return m, err
+	}
+	return m, errors.Join(errs...)
+}
+
+func (s *Schema) decodeJSON(b []byte, keys []string, errs *[]error) (Map[string, any], error) {
+
+	m := New[string, any]()
+
+	err := decodeJSONObject(b, func(key string, raw json.RawMessage, _ int64, _ string) error {
+
+		p := append(keys[:len(keys):len(keys)], key)
+
+		f, ok := s.match(key)
+		switch {
+
+		case !ok && !s.AllowUnknown:
+			*errs = append(*errs, &FieldError{p, ErrUnknownKey})
+
+		case !ok:
+			m.Set(key, raw)
+
+		case f.nested != nil:
+			v, err := f.nested.decodeJSON(raw, p, errs)
+			if err != nil {
+				*errs = append(*errs, &FieldError{p, err})
+				return nil
+			}
+			m.Set(key, v)
+
+		default:
+			v := reflect.New(f.typ)
+			if err := json.Unmarshal(raw, v.Interface()); err != nil {
+				*errs = append(*errs, &FieldError{p, err})
+				return nil
+			}
+			m.Set(key, v.Elem().Interface())
+		}
+
+		return nil
+	})
+
+	return m, err
+}
+
+// DecodeYAML decodes the YAML mapping node according to the schema.
+// See [Schema.DecodeJSON].
+func (s *Schema) DecodeYAML(node *yaml.Node) (Map[string, any], error) {
+	var errs []error
+	m, err := s.decodeYAML(node, nil, &errs)
+	if err != nil {
+		return m, err
+	}
+	return m, errors.Join(errs...)
+}
+
+func (s *Schema) decodeYAML(node *yaml.Node, keys []string, errs *[]error) (Map[string, any], error) {
+
+	m := New[string, any]()
+
+	err := decodeYAMLMapping(node, func(key string, _ yaml.Node, _, valNode *yaml.Node) error {
+
+		p := append(keys[:len(keys):len(keys)], key)
+
+		f, ok := s.match(key)
+		switch {
+
+		case !ok && !s.AllowUnknown:
+			*errs = append(*errs, &FieldError{p, ErrUnknownKey})
+
+		case !ok:
+			m.Set(key, valNode)
+
+		case f.nested != nil:
+			v, err := f.nested.decodeYAML(valNode, p, errs)
+			if err != nil {
+				*errs = append(*errs, &FieldError{p, err})
+				return nil
+			}
+			m.Set(key, v)
+
+		default:
+			v := reflect.New(f.typ)
+			if err := valNode.Decode(v.Interface()); err != nil {
+				*errs = append(*errs, &FieldError{p, err})
+				return nil
+			}
+			m.Set(key, v.Elem().Interface())
+		}
+
+		return nil
+	})
+
+	return m, err
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+	"go.yaml.in/yaml/v4"
+)
+
+// schemaDecoders decode the same sources as JSON and as YAML,
+// JSON being valid YAML.
+var schemaDecoders = []struct {
+	name   string
+	decode func(s *omap.Schema, src string) (omap.Map[string, any], error)
+}{
+	{
+		"json",
+		func(s *omap.Schema, src string) (omap.Map[string, any], error) {
+			return s.DecodeJSON([]byte(src))
+		},
+	},
+	{
+		"yaml",
+		func(s *omap.Schema, src string) (omap.Map[string, any], error) {
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(src), &node); err != nil {
+				return omap.Map[string, any]{}, err
+			}
+			return s.DecodeYAML(&node)
+		},
+	},
+}
+
+func testSchema() *omap.Schema {
+
+	var inner omap.Schema
+	omap.Register[float64](&inner, "score")
+
+	var s omap.Schema
+	omap.Register[int](&s, "id")
+	omap.Register[string](&s, "i*")
+	omap.Register[[]string](&s, "tag_?")
+	s.Nested("meta", &inner)
+	omap.Register[bool](&s, "meta*")
+
+	return &s
+}
+
+func TestSchema(t *testing.T) {
+
+	const src = `{"id": 1, "ix": "s", "tag_a": ["x", "y"], "meta": {"score": 1.5}, "metadata": true}`
+
+	for _, d := range schemaDecoders {
+
+		m, err := d.decode(testSchema(), src)
+		if err != nil {
+			t.Fatalf("%s: %v", d.name, err)
+		}
+
+		// Earlier patterns take precedence: "id" is an int, not a string.
+		id, _ := m.Get("id")
+		ix, _ := m.Get("ix")
+		tag, _ := m.Get("tag_a")
+		metadata, _ := m.Get("metadata")
+
+		got := []any{id, ix, tag, metadata}
+		want := []any{1, "s", []string{"x", "y"}, true}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: incorrect result (-want +got):\n%s", d.name, diff)
+		}
+
+		if diff := cmp.Diff([]string{"id", "ix", "tag_a", "meta", "metadata"}, keys(m)); diff != "" {
+			t.Errorf("%s: keys: incorrect result (-want +got):\n%s", d.name, diff)
+		}
+
+		meta, _ := m.Get("meta")
+		nested, ok := meta.(omap.Map[string, any])
+		if !ok {
+			t.Fatalf("%s: meta is %T, want omap.Map[string, any]", d.name, meta)
+		}
+		if score, _ := nested.Get("score"); score != 1.5 {
+			t.Errorf("%s: meta.score = %v (%T), want 1.5", d.name, score, score)
+		}
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+
+	const src = `{
+		"id": "x",
+		"ix": "ok",
+		"unknown": 1,
+		"meta": {"score": "bad", "other": 2, "score": 3},
+		"metadata": 4
+	}`
+
+	type fieldError struct {
+		Path    []string
+		Unknown bool
+	}
+
+	want := []fieldError{
+		{[]string{"id"}, false},
+		{[]string{"unknown"}, true},
+		{[]string{"meta", "score"}, false},
+		{[]string{"meta", "other"}, true},
+		{[]string{"metadata"}, false},
+	}
+
+	for _, d := range schemaDecoders {
+
+		m, err := d.decode(testSchema(), src)
+		if err == nil {
+			t.Fatalf("%s: no error", d.name)
+		}
+
+		var got []fieldError
+		for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var fe *omap.FieldError
+			if !errors.As(err, &fe) {
+				t.Fatalf("%s: %v is not a *FieldError", d.name, err)
+			}
+			got = append(got, fieldError{fe.Path, errors.Is(err, omap.ErrUnknownKey)})
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: errors: incorrect result (-want +got):\n%s", d.name, diff)
+		}
+
+		// The keys that decoded successfully are kept.
+		if diff := cmp.Diff([]string{"ix", "meta"}, keys(m)); diff != "" {
+			t.Errorf("%s: keys: incorrect result (-want +got):\n%s", d.name, diff)
+		}
+		meta, _ := m.Get("meta")
+		if score, _ := meta.(omap.Map[string, any]).Get("score"); score != 3.0 {
+			t.Errorf("%s: meta.score = %v, want 3", d.name, score)
+		}
+	}
+
+	// Error messages hold the path as a JSON pointer.
+	var s omap.Schema
+	s.Nested("a/b", testSchema())
+	_, err := s.DecodeJSON([]byte(`{"a/b": {"meta": {"x~": 1}}}`))
+	if want := "/a~1b/meta/x~0: unknown key"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+
+	// Nested values must be objects.
+	for _, d := range schemaDecoders {
+		_, err := d.decode(testSchema(), `{"meta": [1]}`)
+		var fe *omap.FieldError
+		if !errors.As(err, &fe) || !cmp.Equal([]string{"meta"}, fe.Path) {
+			t.Errorf("%s: nested array: error = %v, want a FieldError for meta", d.name, err)
+		}
+	}
+
+	// Top-level values must be objects.
+	for _, d := range schemaDecoders {
+		_, err := d.decode(testSchema(), `[1]`)
+		var fe *omap.FieldError
+		if err == nil || errors.As(err, &fe) {
+			t.Errorf("%s: top-level array: error = %v, want a non-field error", d.name, err)
+		}
+	}
+}
+
+func TestSchemaAllowUnknown(t *testing.T) {
+
+	const src = `{"id": 1, "extra": {"a": [1, 2]}}`
+
+	for _, d := range schemaDecoders {
+
+		s := testSchema()
+		s.AllowUnknown = true
+
+		m, err := d.decode(s, src)
+		if err != nil {
+			t.Fatalf("%s: %v", d.name, err)
+		}
+
+		extra, _ := m.Get("extra")
+		switch d.name {
+		case "json":
+			raw, ok := extra.(json.RawMessage)
+			if !ok || string(raw) != `{"a": [1, 2]}` {
+				t.Errorf("json: extra = %#v, want the raw message", extra)
+			}
+		case "yaml":
+			node, ok := extra.(*yaml.Node)
+			if !ok || node.Kind != yaml.MappingNode {
+				t.Errorf("yaml: extra = %#v, want a mapping node", extra)
+			}
+		}
+	}
+}
+
+func TestSchemaBadPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register with a malformed pattern didn't panic")
+		}
+	}()
+	var s omap.Schema
+	omap.Register[int](&s, "[")
+}
+
+func keys[V any](m omap.Map[string, V]) []string {
+	k := []string{}
+	for key := range m.Keys() {
+		k = append(k, key)
+	}
+	return k
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Set is an insertion-ordered set.
+//
+// Like [Map], a Set is a reference to its elements,
+// so copies of a Set share them.
+type Set[K comparable] struct {
+	*set[K]
+}
+
+type set[K comparable] struct {
+	elems []K
+	index map[K]int // The position of each element in elems.
+}
+
+func NewSet[K comparable](size ...int) Set[K] {
+	s := Set[K]{
+		set: new(set[K]),
+	}
+	if len(size) > 0 {
+		s.elems = make([]K, 0, size[0])
+		s.index = make(map[K]int, size[0])
+	} else {
+		s.index = make(map[K]int)
+	}
+	return s
+}
+
+// SetOf returns a new set of the given elements,
+// in the order of their first occurrence.
+func SetOf[K comparable](elems ...K) Set[K] {
+	s := NewSet[K](len(elems))
+	for _, k := range elems {
+		s.Add(k)
+	}
+	return s
+}
+
+func (s Set[K]) IsNil() bool {
+	return s.set == nil
+}
+
+// Add appends k to the set and reports whether it was added,
+// i.e. whether it wasn't already in the set.
+func (s *Set[K]) Add(k K) bool {
+	s.init()
+	if _, ok := s.index[k]; ok {
+		return false
+	}
+	s.index[k] = len(s.elems)
+	s.elems = append(s.elems, k)
+	return true
+}
+
+// Remove removes k from the set and reports whether it was in the set.
+// It takes time linear in the number of elements after k.
+func (s *Set[K]) Remove(k K) bool {
+	if s.IsNil() {
+		return false
+	}
+	i, ok := s.index[k]
+	if !ok {
+		return false
+	}
+	delete(s.index, k)
+	s.elems = slices.Delete(s.elems, i, i+1)
+	for j, e := range s.elems[i:] {
+		s.index[e] = i + j
+	}
+	return true
+}
+
+func (s Set[K]) Has(k K) bool {
+	if s.IsNil() {
+		return false
+	}
+	_, ok := s.index[k]
+	return ok
+}
+
+func (s Set[K]) Len() int {
+	if s.IsNil() {
+		return 0
+	}
+	return len(s.elems)
+}
+
+// All returns an iterator over the elements of the set in order.
+func (s Set[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		if s.IsNil() {
+			return
+		}
+		for _, k := range s.elems {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Union returns a new set of the elements of s
+// followed by the elements of other that are not in s.
+func (s Set[K]) Union(other Set[K]) Set[K] {
+	u := NewSet[K](s.Len() + other.Len())
+	for k := range s.All() {
+		u.Add(k)
+	}
+	for k := range other.All() {
+		u.Add(k)
+	}
+	return u
+}
+
+// Intersect returns a new set of the elements of s
+// that are also in other, in the order of s.
+func (s Set[K]) Intersect(other Set[K]) Set[K] {
+	return s.filter(other.Has)
+}
+
+// Difference returns a new set of the elements of s
+// that are not in other, in the order of s.
+func (s Set[K]) Difference(other Set[K]) Set[K] {
+	return s.filter(func(k K) bool {
+		return !other.Has(k)
+	})
+}
+
+func (s Set[K]) filter(keep func(K) bool) Set[K] {
+	x := NewSet[K]()
+	for k := range s.All() {
+		if keep(k) {
+			x.Add(k)
+		}
+	}
+	return x
+}
+
+func (s *Set[K]) init() {
+	if s.set == nil {
+		*s = NewSet[K]()
+	}
+}
+
+func (s Set[K]) String() string {
+	var sb strings.Builder
+	sb.WriteString("omap.Set[")
+	i := 0
+	for k := range s.All() {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "%v", k)
+		i++
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// MarshalJSON encodes the set as a JSON array.
+func (s Set[K]) MarshalJSON() ([]byte, error) {
+	if s.IsNil() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(slices.Collect(s.All()))
+}
+
+// UnmarshalJSON adds the elements of a JSON array to the set.
+// A JSON null leaves the set unchanged.
+func (s *Set[K]) UnmarshalJSON(b []byte) error {
+	if isJSONNull(b) {
+		return nil
+	}
+	var elems []K
+	if err := json.Unmarshal(b, &elems); err != nil {
+		return err
+	}
+	s.init()
+	for _, k := range elems {
+		s.Add(k)
+	}
+	return nil
+}
+
+// MarshalYAML encodes the set as a YAML sequence node.
+func (s Set[K]) MarshalYAML() (any, error) {
+
+	node := &yaml.Node{
+		Kind: yaml.SequenceNode,
+	}
+
+	for k := range s.All() {
+		elem, err := encodeYAMLNode(k)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, elem)
+	}
+
+	return node, nil
+}
+
+// UnmarshalYAML adds the elements of a YAML sequence node to the set.
+func (s *Set[K]) UnmarshalYAML(node *yaml.Node) error {
+
+	node = unwrapYAMLDocument(node)
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("expected yaml sequence node, got %v", node.Kind)
+	}
+
+	s.init()
+
+	for _, n := range node.Content {
+		var k K
+		if err := n.Decode(&k); err != nil {
+			return err
+		}
+		s.Add(k)
+	}
+
+	return nil
+}
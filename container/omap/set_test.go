@@ -0,0 +1,173 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestSet(t *testing.T) {
+
+	var s omap.Set[string]
+
+	if s.Len() != 0 || s.Has("a") || s.Remove("a") {
+		t.Error("nil set is not empty")
+	}
+
+	if !s.Add("c") || !s.Add("a") || s.Add("c") || !s.Add("b") {
+		t.Error("Add reported the wrong result")
+	}
+	if diff := cmp.Diff([]string{"c", "a", "b"}, slices.Collect(s.All())); diff != "" {
+		t.Errorf("Add: incorrect result (-want +got):\n%s", diff)
+	}
+	if !s.Has("a") || s.Has("x") {
+		t.Error("Has reported the wrong result")
+	}
+
+	if !s.Remove("a") || s.Remove("a") {
+		t.Error("Remove reported the wrong result")
+	}
+	s.Add("a")
+	if diff := cmp.Diff([]string{"c", "b", "a"}, slices.Collect(s.All())); diff != "" {
+		t.Errorf("Remove and Add: incorrect result (-want +got):\n%s", diff)
+	}
+
+	if got, want := s.String(), "omap.Set[c b a]"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
+
+func TestSetRemove(t *testing.T) {
+
+	var s omap.Set[int]
+	for i := range 1000 {
+		s.Add(i)
+	}
+	c := s
+
+	// Removing elements must keep the positions
+	// of the elements after them up to date.
+	var want []int
+	for i := range 1000 {
+		if i%3 == 0 {
+			if !c.Remove(i) {
+				t.Fatalf("Remove(%d) = false", i)
+			}
+		} else {
+			want = append(want, i)
+		}
+	}
+	for i := range 1000 {
+		if s.Has(i) != (i%3 != 0) {
+			t.Fatalf("Has(%d) = %v after removals", i, s.Has(i))
+		}
+	}
+	for _, i := range []int{999, 1, 500} {
+		s.Remove(i)
+		s.Add(i)
+		want = append(slices.DeleteFunc(want, func(k int) bool { return k == i }), i)
+	}
+	if diff := cmp.Diff(want, slices.Collect(s.All())); diff != "" {
+		t.Errorf("Remove: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+
+	a := omap.SetOf("d", "b", "d", "a", "c")
+	b := omap.SetOf("e", "c", "b", "f")
+
+	tests := []struct {
+		title string
+		got   omap.Set[string]
+		want  []string
+	}{
+		{"SetOf", a, []string{"d", "b", "a", "c"}},
+		{"Union", a.Union(b), []string{"d", "b", "a", "c", "e", "f"}},
+		{"Union reversed", b.Union(a), []string{"e", "c", "b", "f", "d", "a"}},
+		{"Intersect", a.Intersect(b), []string{"b", "c"}},
+		{"Intersect reversed", b.Intersect(a), []string{"c", "b"}},
+		{"Difference", a.Difference(b), []string{"d", "a"}},
+		{"Difference reversed", b.Difference(a), []string{"e", "f"}},
+		{"Union with nil", a.Union(omap.Set[string]{}), []string{"d", "b", "a", "c"}},
+		{"Intersect with nil", a.Intersect(omap.Set[string]{}), []string{}},
+		{"Difference of nil", omap.Set[string]{}.Difference(a), []string{}},
+	}
+
+	for _, tt := range tests {
+		got := append([]string{}, slices.Collect(tt.got.All())...)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: incorrect result (-want +got):\n%s", tt.title, diff)
+		}
+	}
+
+	// The operations return new sets.
+	u := a.Union(b)
+	u.Add("x")
+	if a.Has("x") {
+		t.Error("Union shares its elements with the receiver")
+	}
+}
+
+func TestSetEncoding(t *testing.T) {
+
+	want := []string{"z", "a", "m"}
+
+	t.Run("json", func(t *testing.T) {
+
+		var s omap.Set[string]
+		if err := json.Unmarshal([]byte(`["z","a","z","m"]`), &s); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, slices.Collect(s.All())); diff != "" {
+			t.Errorf("json.Unmarshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(`["z","a","m"]`, string(b)); diff != "" {
+			t.Errorf("json.Marshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		var nilSet omap.Set[string]
+		if b, _ := json.Marshal(nilSet); string(b) != "null" {
+			t.Errorf("json.Marshal of nil set = %s, want null", b)
+		}
+
+		if err := json.Unmarshal([]byte(`{"a":1}`), &s); err == nil {
+			t.Error("json.Unmarshal of an object succeeded")
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+
+		var s omap.Set[string]
+		if err := yaml.Unmarshal([]byte("[z, a, z, m]"), &s); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, slices.Collect(s.All())); diff != "" {
+			t.Errorf("yaml.Unmarshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		b, err := yaml.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff("- z\n- a\n- m\n", string(b)); diff != "" {
+			t.Errorf("yaml.Marshal: incorrect result (-want +got):\n%s", diff)
+		}
+
+		if err := yaml.Unmarshal([]byte("a: 1"), &s); err == nil {
+			t.Error("yaml.Unmarshal of a mapping succeeded")
+		}
+	})
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Flag returns a [flag.Value] that sets a key-value pair in m
+// for each "key=value" argument, in the order of the arguments:
+//
+//	flag.Var(m.Flag(), "set", "set `key=value`")
+//
+// Keys and values are parsed from text like JSON object keys,
+// and values may also be booleans or floats.
+// When V is any, values are stored as strings, unparsed.
+func (m *Map[K, V]) Flag() flag.Value {
+	return &mapFlag[K, V]{m}
+}
+
+type mapFlag[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+func (f *mapFlag[K, V]) String() string {
+	if f.m == nil || f.m.Len() == 0 {
+		return ""
+	}
+	return f.m.String()
+}
+
+func (f *mapFlag[K, V]) Set(s string) error {
+
+	k, v, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+
+	key, err := parseKeyText[K](k)
+	if err != nil {
+		return err
+	}
+
+	val, err := parseValueText[V](v)
+	if err != nil {
+		return err
+	}
+
+	f.m.Set(key, val)
+	return nil
+}
+
+// parseValueText parses s into a V
+// like [parseKeyText], with support for more types.
+func parseValueText[V any](s string) (val V, err error) {
+
+	val, err = parseKeyText[V](s)
+	if !errors.Is(err, errUnsupportedKey) {
+		return val, err
+	}
+	err = nil
+
+	v := reflect.ValueOf(&val).Elem()
+
+	switch v.Kind() {
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return val, fmt.Errorf("unsupported value type %v", v.Type())
+		}
+		v.Set(reflect.ValueOf(s))
+	default:
+		return val, fmt.Errorf("unsupported value type %v", v.Type())
+	}
+
+	if err != nil {
+		return val, fmt.Errorf("could not decode value %q into %T: %w", s, val, err)
+	}
+
+	return val, nil
+}
+
+// WriteTable writes the map to w as a table
+// with a line per key and the values aligned:
+//
+//	name     toolbox
+//	version  1.2
+//
+// Keys and values are formatted like in [Map.String],
+// and those with non-printable characters are quoted.
+func (m Map[K, V]) WriteTable(w io.Writer) error {
+
+	pairs := textPairs(m)
+
+	width := 0
+	for i, p := range pairs {
+		pairs[i].key = quoteText(p.key)
+		pairs[i].val = quoteText(p.val)
+		width = max(width, utf8.RuneCountInString(pairs[i].key))
+	}
+
+	var sb strings.Builder
+	for _, p := range pairs {
+		sb.WriteString(p.key)
+		sb.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(p.key)+2))
+		sb.WriteString(p.val)
+		sb.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+type textPair struct {
+	key, val string
+	sub      textMap
+}
+
+// textMap is implemented by the maps of this package
+// that are written as sections of INI files.
+type textMap interface {
+	textPairs() []textPair
+}
+
+func (m Map[K, V]) textPairs() []textPair {
+	return textPairs(m)
+}
+
+func textPairs[K comparable, V any](m Map[K, V]) []textPair {
+	var pairs []textPair
+	for k, v := range m.All() {
+		sub, _ := any(v).(textMap)
+		pairs = append(pairs, textPair{fmt.Sprint(k), fmt.Sprint(v), sub})
+	}
+	return pairs
+}
+
+// quoteText quotes s if it has non-printable characters.
+func quoteText(s string) string {
+	if strings.ContainsFunc(s, isNotPrint) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func isNotPrint(r rune) bool {
+	return !unicode.IsPrint(r)
+}
+
+// WriteINI writes the map to w as an INI file.
+//
+// Values that are maps of this package become sections,
+// with nested sections named by joining the keys with dots.
+// As INI keys belong to the last section preceding them,
+// the keys of a map are written before its sections.
+//
+// Keys and values that could be misread are quoted,
+// with Go escape sequences.
+func (m Map[K, V]) WriteINI(w io.Writer) error {
+	var sb strings.Builder
+	if err := writeINI(&sb, "", textPairs(m)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeINI(sb *strings.Builder, section string, pairs []textPair) error {
+
+	for _, p := range pairs {
+		if p.sub != nil {
+			continue
+		}
+		sb.WriteString(quoteINI(p.key))
+		sb.WriteString(" = ")
+		sb.WriteString(quoteINI(p.val))
+		sb.WriteByte('\n')
+	}
+
+	for _, p := range pairs {
+		if p.sub == nil {
+			continue
+		}
+		name := p.key
+		if section != "" {
+			name = section + "." + name
+		}
+		if strings.ContainsAny(name, "[]") || strings.ContainsFunc(name, isNotPrint) {
+			return fmt.Errorf("invalid ini section name %q", name)
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		fmt.Fprintf(sb, "[%s]\n", name)
+		if err := writeINI(sb, name, p.sub.textPairs()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func quoteINI(s string) string {
+	if s == "" || strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s, "=;#[]\"'\\") || strings.ContainsFunc(s, isNotPrint) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// WriteEnv writes the map to w as an env file,
+// with a KEY=value line per key.
+//
+// Keys must be valid environment variable names.
+// Values other than plain words are single-quoted,
+// the way POSIX shells and most env file parsers read them.
+func (m Map[K, V]) WriteEnv(w io.Writer) error {
+
+	var sb strings.Builder
+
+	for k, v := range m.All() {
+
+		key := fmt.Sprint(k)
+		if !isEnvName(key) {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+		if _, ok := any(v).(textMap); ok {
+			return fmt.Errorf("cannot write map value of %q to env file", key)
+		}
+
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(quoteEnv(fmt.Sprint(v)))
+		sb.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func isEnvName(s string) bool {
+	for i, r := range s {
+		if r != '_' && !isASCIILetter(r) && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isASCIILetter(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+}
+
+func quoteEnv(s string) string {
+	plain := !strings.ContainsFunc(s, func(r rune) bool {
+		return !isASCIILetter(r) && (r < '0' || r > '9') && !strings.ContainsRune("_-.,:/@%+", r)
+	})
+	if plain && s != "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
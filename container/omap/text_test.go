@@ -0,0 +1,211 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func TestFlag(t *testing.T) {
+
+	var ints omap.Map[string, int]
+	var floats omap.Map[int, float64]
+	var bools omap.Map[string, bool]
+	var anys omap.Map[string, any]
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(ints.Flag(), "int", "")
+	fs.Var(floats.Flag(), "float", "")
+	fs.Var(bools.Flag(), "bool", "")
+	fs.Var(anys.Flag(), "any", "")
+
+	err := fs.Parse([]string{
+		"-int", "b=2",
+		"-int", "a=1",
+		"-int", "b=3",
+		"-float", "2=1.5",
+		"-float", "-1=1e3",
+		"-bool", "x=true",
+		"-bool", "y=0",
+		"-any", "n=1",
+		"-any", "s=a=b",
+		"-any", "e=",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{ints.String(), floats.String(), bools.String(), anys.String()}
+	want := []string{
+		"omap[b:3 a:1]",
+		"omap[2:1.5 -1:1000]",
+		"omap[x:true y:false]",
+		"omap[n:1 s:a=b e:]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Flag: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Values are stored unparsed when V is any.
+	if n, _ := anys.Get("n"); n != "1" {
+		t.Errorf("any value = %#v, want \"1\"", n)
+	}
+
+	for _, arg := range []string{"noequals", "x=1", "1=x"} {
+		var m omap.Map[int, int]
+		if err := m.Flag().Set(arg); err == nil {
+			t.Errorf("Set(%q) succeeded", arg)
+		}
+	}
+
+	var unsupported omap.Map[string, []int]
+	if err := unsupported.Flag().Set("a=1"); err == nil {
+		t.Error("Set with an unsupported value type succeeded")
+	}
+
+	var empty omap.Map[string, int]
+	if s := empty.Flag().String(); s != "" {
+		t.Errorf("String of an empty flag = %q", s)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+
+	m := omap.New[string, any]()
+	m.Set("name", "toolbox")
+	m.Set("version", 1.2)
+	m.Set("héllo", "wörld")
+	m.Set("tab\tkey", "new\nline")
+	m.Set("empty", "")
+
+	var sb strings.Builder
+	if err := m.WriteTable(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "" +
+		"name        toolbox\n" +
+		"version     1.2\n" +
+		"héllo       wörld\n" +
+		"\"tab\\tkey\"  \"new\\nline\"\n" +
+		"empty       \n"
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("WriteTable: incorrect result (-want +got):\n%s", diff)
+	}
+
+	sb.Reset()
+	if err := (omap.Map[string, int]{}).WriteTable(&sb); err != nil || sb.Len() != 0 {
+		t.Errorf("WriteTable of a nil map = %q, %v", sb.String(), err)
+	}
+}
+
+func TestWriteINI(t *testing.T) {
+
+	db := omap.New[string, any]()
+	db.Set("host", "localhost")
+	db.Set("port", 5432)
+
+	replica := omap.New[string, string]()
+	replica.Set("host", "replica")
+	db.Set("replica", replica)
+	db.Set("user", "admin")
+
+	m := omap.New[string, any]()
+	m.Set("name", "app")
+	m.Set("db", db)
+	m.Set("note", " padded; with = signs ")
+	m.Set("quote", `say "hi"`)
+	m.Set("empty", "")
+	m.Set("a key", "line\nbreak")
+
+	var sb strings.Builder
+	if err := m.WriteINI(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `name = app
+note = " padded; with = signs "
+quote = "say \"hi\""
+empty = ""
+a key = "line\nbreak"
+
+[db]
+host = localhost
+port = 5432
+user = admin
+
+[db.replica]
+host = replica
+`
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("WriteINI: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Sections without keys before them don't start with a blank line.
+	only := omap.New[string, any]()
+	only.Set("s", replica)
+	sb.Reset()
+	if err := only.WriteINI(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("[s]\nhost = replica\n", sb.String()); diff != "" {
+		t.Errorf("WriteINI of sections only: incorrect result (-want +got):\n%s", diff)
+	}
+
+	for _, name := range []string{"a]b", "a[b", "a\nb"} {
+		bad := omap.New[string, any]()
+		bad.Set(name, replica)
+		if err := bad.WriteINI(io.Discard); err == nil {
+			t.Errorf("WriteINI with section %q succeeded", name)
+		}
+	}
+}
+
+func TestWriteEnv(t *testing.T) {
+
+	m := omap.New[string, any]()
+	m.Set("PATH", "/usr/bin:/bin")
+	m.Set("_count2", 3)
+	m.Set("MSG", "hello world")
+	m.Set("QUOTE", "it's")
+	m.Set("EMPTY", "")
+	m.Set("VARS", "$HOME `x`")
+
+	var sb strings.Builder
+	if err := m.WriteEnv(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `PATH=/usr/bin:/bin
+_count2=3
+MSG='hello world'
+QUOTE='it'\''s'
+EMPTY=''
+VARS='$HOME ` + "`x`" + `'
+`
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("WriteEnv: incorrect result (-want +got):\n%s", diff)
+	}
+
+	for _, name := range []string{"", "1A", "A-B", "É"} {
+		bad := omap.New[string, int]()
+		bad.Set(name, 1)
+		if err := bad.WriteEnv(io.Discard); err == nil {
+			t.Errorf("WriteEnv with name %q succeeded", name)
+		}
+	}
+
+	nested := omap.New[string, any]()
+	nested.Set("A", omap.New[string, int]())
+	if err := nested.WriteEnv(io.Discard); err == nil {
+		t.Error("WriteEnv with a map value succeeded")
+	}
+}
@@ -0,0 +1,1080 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MarshalTOML encodes the map as a TOML document,
+// keeping the order of its keys and of the keys of nested maps.
+//
+// Values of type string, bool, integers, floats, [time.Time],
+// slices and Map[string, any] are encoded natively;
+// other values are converted through their JSON encoding.
+// TOML has no null, so nil values are an error.
+//
+// Nested tables and arrays of tables are written as [table] and
+// [[array]] sections where possible. A table that is followed by
+// a plain key in its parent is written inline to keep the order.
+func (m Map[K, V]) MarshalTOML() ([]byte, error) {
+
+	root := New[string, any](m.Len())
+
+	for k, v := range m.All() {
+		key, err := keyText(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := tomlValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		root.Set(key, val)
+	}
+
+	e := &tomlEncoder{}
+	if err := e.table(nil, root, false); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// UnmarshalTOML decodes the TOML document b into m,
+// keeping the order in which keys and tables first appear.
+//
+// When V is any, tables are decoded into Map[string, any],
+// arrays into []any, integers into int64, floats into float64
+// and date-times into [time.Time]. Other value types are
+// decoded through the JSON encoding of the TOML values.
+func (m *Map[K, V]) UnmarshalTOML(b []byte) error {
+
+	m.init()
+
+	p := &tomlParser{
+		b:      b,
+		line:   1,
+		tables: make(map[*omap[string, any]]tomlTableKind),
+	}
+
+	root, err := p.parse()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range root.s {
+
+		key, err := decodeJSONKey[K](t.key)
+		if err != nil {
+			return err
+		}
+
+		var val V
+
+		if p, ok := any(&val).(*any); ok {
+			*p = t.val
+		} else {
+			e := newValueEncoder(nil)
+			if err := e.encode(t.val, rawString{}); err != nil {
+				return err
+			}
+			if err := json.Unmarshal(e.buf.Bytes(), &val); err != nil {
+				return fmt.Errorf("key %q: %w", t.key, err)
+			}
+		}
+
+		m.Set(key, val)
+	}
+
+	return nil
+}
+
+// Locations marking the TOML local date-time types
+// in decoded [time.Time] values.
+var (
+	tomlLocalDatetime = time.FixedZone("toml-local-datetime", 0)
+	tomlLocalDate     = time.FixedZone("toml-local-date", 0)
+	tomlLocalTime     = time.FixedZone("toml-local-time", 0)
+)
+
+// tomlValue converts v into a tree made of
+// string, bool, int64, float64, time.Time, []any and Map[string, any].
+func tomlValue(v any) (any, error) {
+
+	switch v := v.(type) {
+
+	case nil:
+		return nil, fmt.Errorf("cannot encode nil value")
+
+	case string, bool, int64, float64, time.Time:
+		return v, nil
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+
+	case []any:
+		a := make([]any, len(v))
+		for i, x := range v {
+			var err error
+			if a[i], err = tomlValue(x); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+
+	case Map[string, any]:
+		if v.IsNil() {
+			return nil, fmt.Errorf("cannot encode nil value")
+		}
+		t := New[string, any](v.Len())
+		for _, x := range v.s {
+			val, err := tomlValue(x.val)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", x.key, err)
+			}
+			t.Set(x.key, val)
+		}
+		return t, nil
+	}
+
+	r := reflect.ValueOf(v)
+
+	switch r.Kind() {
+	case reflect.String:
+		return r.String(), nil
+	case reflect.Bool:
+		return r.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if r.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("integer %d overflows int64", r.Uint())
+		}
+		return int64(r.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return r.Float(), nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var x Value
+	if err := x.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+
+	return tomlValue(x.V)
+}
+
+type tomlEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *tomlEncoder) table(path []string, t Map[string, any], arrayElem bool) error {
+
+	// Everything up to the last plain key-value pair has to be
+	// written before any section header, so tables among those
+	// are written inline.
+	last := -1
+	for i, x := range t.s {
+		if !isTOMLTable(x.val) && !isTOMLTableArray(x.val) {
+			last = i
+		}
+	}
+
+	if arrayElem || (len(path) > 0 && (last >= 0 || len(t.s) == 0)) {
+		if e.buf.Len() > 0 {
+			e.buf.WriteByte('\n')
+		}
+		if arrayElem {
+			fmt.Fprintf(&e.buf, "[[%s]]\n", tomlPath(path))
+		} else {
+			fmt.Fprintf(&e.buf, "[%s]\n", tomlPath(path))
+		}
+	}
+
+	for _, x := range t.s[:last+1] {
+		e.buf.WriteString(tomlKey(x.key))
+		e.buf.WriteString(" = ")
+		if err := e.inline(x.val); err != nil {
+			return err
+		}
+		e.buf.WriteByte('\n')
+	}
+
+	for _, x := range t.s[last+1:] {
+		p := append(path[:len(path):len(path)], x.key)
+		if sub, ok := x.val.(Map[string, any]); ok {
+			if err := e.table(p, sub, false); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, elem := range x.val.([]any) {
+			if err := e.table(p, elem.(Map[string, any]), true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *tomlEncoder) inline(v any) error {
+
+	switch v := v.(type) {
+
+	case string:
+		e.buf.WriteString(tomlString(v))
+
+	case bool:
+		e.buf.WriteString(strconv.FormatBool(v))
+
+	case int64:
+		e.buf.WriteString(strconv.FormatInt(v, 10))
+
+	case float64:
+		e.buf.WriteString(tomlFloat(v))
+
+	case time.Time:
+		e.buf.WriteString(tomlTime(v))
+
+	case []any:
+		e.buf.WriteByte('[')
+		for i, x := range v {
+			if i > 0 {
+				e.buf.WriteString(", ")
+			}
+			if err := e.inline(x); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+
+	case Map[string, any]:
+		e.buf.WriteByte('{')
+		for i, x := range v.s {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.buf.WriteByte(' ')
+			e.buf.WriteString(tomlKey(x.key))
+			e.buf.WriteString(" = ")
+			if err := e.inline(x.val); err != nil {
+				return err
+			}
+		}
+		if v.Len() > 0 {
+			e.buf.WriteByte(' ')
+		}
+		e.buf.WriteByte('}')
+
+	default:
+		return fmt.Errorf("unsupported toml value type %T", v)
+	}
+
+	return nil
+}
+
+func isTOMLTable(v any) bool {
+	_, ok := v.(Map[string, any])
+	return ok
+}
+
+func isTOMLTableArray(v any) bool {
+	a, ok := v.([]any)
+	if !ok || len(a) == 0 {
+		return false
+	}
+	for _, x := range a {
+		if !isTOMLTable(x) {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlPath(path []string) string {
+	s := make([]string, len(path))
+	for i, p := range path {
+		s[i] = tomlKey(p)
+	}
+	return strings.Join(s, ".")
+}
+
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i := 0; i < len(key); i++ {
+		if !isTOMLBareKeyChar(key[i]) {
+			return tomlString(key)
+		}
+	}
+	return key
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '_' || c == '-'
+}
+
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+func tomlTime(t time.Time) string {
+	switch t.Location() {
+	case tomlLocalDatetime:
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case tomlLocalDate:
+		return t.Format("2006-01-02")
+	case tomlLocalTime:
+		return t.Format("15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
+
+type tomlTableKind uint8
+
+const (
+	tomlImplicit tomlTableKind = iota // created as a parent of a [table] header
+	tomlExplicit                      // defined by a [table] header
+	tomlDotted                        // created by a dotted key
+	tomlInline                        // defined by an inline table
+)
+
+// tomlTableArray is an array of tables while it's being parsed.
+// Unlike []any, it can be appended to through the parent table.
+type tomlTableArray struct {
+	tables []Map[string, any]
+}
+
+type tomlParser struct {
+	b      []byte
+	i      int
+	line   int
+	tables map[*omap[string, any]]tomlTableKind
+}
+
+func (p *tomlParser) parse() (Map[string, any], error) {
+
+	root := New[string, any]()
+	cur := root
+
+	for {
+
+		p.skipSpace()
+
+		if p.i == len(p.b) {
+			break
+		}
+
+		switch p.b[p.i] {
+
+		case '#', '\r', '\n':
+			if err := p.endOfLine(); err != nil {
+				return root, err
+			}
+
+		case '[':
+			var err error
+			cur, err = p.header(root)
+			if err != nil {
+				return root, err
+			}
+			if err := p.endOfLine(); err != nil {
+				return root, err
+			}
+
+		default:
+			if err := p.keyValue(cur); err != nil {
+				return root, err
+			}
+			if err := p.endOfLine(); err != nil {
+				return root, err
+			}
+		}
+	}
+
+	tomlFinish(root)
+	return root, nil
+}
+
+// header parses a [table] or [[array]] header
+// and returns the table that following key-value pairs go into.
+func (p *tomlParser) header(root Map[string, any]) (Map[string, any], error) {
+
+	p.i++
+	array := p.consume('[')
+
+	p.skipSpace()
+	path, err := p.key()
+	if err != nil {
+		return root, err
+	}
+	p.skipSpace()
+
+	if !p.consume(']') || (array && !p.consume(']')) {
+		return root, p.errorf("expected ']' after table header")
+	}
+
+	t := root
+	for _, k := range path[:len(path)-1] {
+		if t, err = p.descend(t, k, tomlImplicit); err != nil {
+			return root, err
+		}
+	}
+
+	k := path[len(path)-1]
+	v, has := t.Get(k)
+
+	if array {
+		sub := New[string, any]()
+		switch v := v.(type) {
+		case nil:
+			if has {
+				return root, p.errorf("key %q is already defined", k)
+			}
+			t.Set(k, &tomlTableArray{[]Map[string, any]{sub}})
+		case *tomlTableArray:
+			v.tables = append(v.tables, sub)
+		default:
+			return root, p.errorf("key %q is already defined", k)
+		}
+		p.tables[sub.omap] = tomlExplicit
+		return sub, nil
+	}
+
+	if !has {
+		sub := New[string, any]()
+		t.Set(k, sub)
+		p.tables[sub.omap] = tomlExplicit
+		return sub, nil
+	}
+
+	sub, ok := v.(Map[string, any])
+	if !ok || p.tables[sub.omap] != tomlImplicit {
+		return root, p.errorf("table %q is already defined", k)
+	}
+	p.tables[sub.omap] = tomlExplicit
+	return sub, nil
+}
+
+// descend returns the table under key k of t,
+// creating it with the given kind if it doesn't exist.
+func (p *tomlParser) descend(t Map[string, any], k string, kind tomlTableKind) (Map[string, any], error) {
+
+	v, has := t.Get(k)
+	if !has {
+		sub := New[string, any]()
+		t.Set(k, sub)
+		p.tables[sub.omap] = kind
+		return sub, nil
+	}
+
+	switch v := v.(type) {
+	case Map[string, any]:
+		existing := p.tables[v.omap]
+		if existing == tomlInline || (kind == tomlDotted && existing != tomlDotted) {
+			return t, p.errorf("cannot extend table %q", k)
+		}
+		return v, nil
+	case *tomlTableArray:
+		if kind == tomlDotted {
+			return t, p.errorf("cannot extend array of tables %q", k)
+		}
+		return v.tables[len(v.tables)-1], nil
+	default:
+		return t, p.errorf("key %q is not a table", k)
+	}
+}
+
+func (p *tomlParser) keyValue(t Map[string, any]) error {
+
+	path, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace()
+	if !p.consume('=') {
+		return p.errorf("expected '=' after key")
+	}
+	p.skipSpace()
+
+	for _, k := range path[:len(path)-1] {
+		if t, err = p.descend(t, k, tomlDotted); err != nil {
+			return err
+		}
+	}
+
+	k := path[len(path)-1]
+	if _, has := t.Get(k); has {
+		return p.errorf("key %q is already defined", k)
+	}
+
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	t.Set(k, v)
+	return nil
+}
+
+// key parses a possibly dotted key.
+func (p *tomlParser) key() ([]string, error) {
+
+	var path []string
+
+	for {
+
+		p.skipSpace()
+
+		if p.i == len(p.b) {
+			return nil, p.errorf("expected key")
+		}
+
+		switch p.b[p.i] {
+
+		case '"', '\'':
+			s, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, s)
+
+		default:
+			start := p.i
+			for p.i < len(p.b) && isTOMLBareKeyChar(p.b[p.i]) {
+				p.i++
+			}
+			if p.i == start {
+				return nil, p.errorf("expected key")
+			}
+			path = append(path, string(p.b[start:p.i]))
+		}
+
+		p.skipSpace()
+		if !p.consume('.') {
+			return path, nil
+		}
+	}
+}
+
+func (p *tomlParser) value() (any, error) {
+
+	if p.i == len(p.b) {
+		return nil, p.errorf("expected value")
+	}
+
+	switch c := p.b[p.i]; {
+
+	case c == '"' || c == '\'':
+		return p.string()
+
+	case c == '[':
+		return p.array()
+
+	case c == '{':
+		return p.inlineTable()
+
+	case p.hasPrefix("true"):
+		p.i += len("true")
+		return true, nil
+
+	case p.hasPrefix("false"):
+		p.i += len("false")
+		return false, nil
+
+	case p.isDatetime():
+		return p.datetime()
+
+	default:
+		return p.number()
+	}
+}
+
+func (p *tomlParser) array() ([]any, error) {
+
+	p.i++
+	a := []any{}
+
+	for {
+
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return a, nil
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+
+		if err := p.skipBlank(); err != nil {
+			return nil, err
+		}
+		if p.consume(']') {
+			return a, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) inlineTable() (Map[string, any], error) {
+
+	p.i++
+	t := New[string, any]()
+	p.tables[t.omap] = tomlInline
+
+	p.skipSpace()
+	if p.consume('}') {
+		return t, nil
+	}
+
+	for {
+
+		if err := p.keyValue(t); err != nil {
+			return t, err
+		}
+
+		p.skipSpace()
+		if p.consume('}') {
+			return t, nil
+		}
+		if !p.consume(',') {
+			return t, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+func (p *tomlParser) string() (string, error) {
+
+	quote := p.b[p.i]
+	multiline := p.hasPrefix(strings.Repeat(string(quote), 3))
+
+	if multiline {
+		p.i += 3
+		// A newline immediately following the opening delimiter is trimmed.
+		if p.hasPrefix("\r\n") {
+			p.i += 2
+			p.line++
+		} else if p.hasPrefix("\n") {
+			p.i++
+			p.line++
+		}
+	} else {
+		p.i++
+	}
+
+	var sb strings.Builder
+
+	for {
+
+		if p.i == len(p.b) {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.b[p.i]
+
+		switch {
+
+		case c == quote && !multiline:
+			p.i++
+			return sb.String(), nil
+
+		case c == quote && p.hasPrefix(strings.Repeat(string(quote), 3)):
+			// Up to two quotes may directly precede the closing delimiter.
+			n := 3
+			for n < 5 && p.i+n < len(p.b) && p.b[p.i+n] == quote {
+				n++
+			}
+			sb.WriteString(strings.Repeat(string(quote), n-3))
+			p.i += n
+			return sb.String(), nil
+
+		case c == '\n':
+			if !multiline {
+				return "", p.errorf("newline in string")
+			}
+			sb.WriteByte(c)
+			p.i++
+			p.line++
+
+		case c == '\\' && quote == '"':
+			if err := p.escape(&sb, multiline); err != nil {
+				return "", err
+			}
+
+		case (c < 0x20 || c == 0x7f) && c != '\t' && !(multiline && p.hasPrefix("\r\n")):
+			// Control characters other than tab must be escaped,
+			// and multiline strings only allow them in newlines.
+			return "", p.errorf("control character %U in string", c)
+
+		default:
+			sb.WriteByte(c)
+			p.i++
+		}
+	}
+}
+
+func (p *tomlParser) escape(sb *strings.Builder, multiline bool) error {
+
+	p.i++
+	if p.i == len(p.b) {
+		return p.errorf("unterminated string")
+	}
+
+	c := p.b[p.i]
+	p.i++
+
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case '"':
+		sb.WriteByte('"')
+	case '\\':
+		sb.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.i+n > len(p.b) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(string(p.b[p.i:p.i+n]), 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape")
+		}
+		sb.WriteRune(rune(r))
+		p.i += n
+	default:
+		// A line ending backslash, which only spaces and tabs
+		// may follow on its line, trims all whitespace
+		// up to the next non-whitespace character.
+		if !multiline || (c != ' ' && c != '\t' && c != '\r' && c != '\n') {
+			return p.errorf("invalid escape sequence \\%c", c)
+		}
+		p.i--
+		p.skipSpace()
+		if !p.hasPrefix("\n") && !p.hasPrefix("\r\n") {
+			return p.errorf("invalid escape sequence: backslash followed by whitespace before the end of the line")
+		}
+		for p.i < len(p.b) && strings.IndexByte(" \t\r\n", p.b[p.i]) != -1 {
+			if p.b[p.i] == '\n' {
+				p.line++
+			}
+			p.i++
+		}
+	}
+
+	return nil
+}
+
+func (p *tomlParser) isDatetime() bool {
+	b := p.b[p.i:]
+	digits := func(n int) bool {
+		if len(b) < n {
+			return false
+		}
+		for _, c := range b[:n] {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return true
+	}
+	return digits(4) && len(b) > 4 && b[4] == '-' ||
+		digits(2) && len(b) > 2 && b[2] == ':'
+}
+
+func (p *tomlParser) datetime() (time.Time, error) {
+
+	start := p.i
+	for p.i < len(p.b) && isTOMLDatetimeChar(p.b[p.i]) {
+		p.i++
+	}
+
+	// A space may separate the date and the time.
+	if p.i-start == 10 && p.i+1 < len(p.b) && p.b[p.i] == ' ' &&
+		p.b[p.i+1] >= '0' && p.b[p.i+1] <= '9' {
+		p.i++
+		for p.i < len(p.b) && isTOMLDatetimeChar(p.b[p.i]) {
+			p.i++
+		}
+	}
+
+	s := strings.ToUpper(string(p.b[start:p.i]))
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+
+	layouts := []struct {
+		layout string
+		loc    *time.Location
+	}{
+		{time.RFC3339Nano, time.UTC},
+		{"2006-01-02T15:04:05.999999999", tomlLocalDatetime},
+		{"2006-01-02", tomlLocalDate},
+		{"15:04:05.999999999", tomlLocalTime},
+	}
+
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l.layout, s, l.loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, p.errorf("invalid date-time %q", s)
+}
+
+func isTOMLDatetimeChar(c byte) bool {
+	return c >= '0' && c <= '9' ||
+		c == '-' || c == ':' || c == '.' || c == '+' ||
+		c == 'T' || c == 't' || c == 'Z' || c == 'z'
+}
+
+func (p *tomlParser) number() (any, error) {
+
+	start := p.i
+	for p.i < len(p.b) && (isTOMLBareKeyChar(p.b[p.i]) || strings.IndexByte("+.", p.b[p.i]) != -1) {
+		p.i++
+	}
+
+	s := string(p.b[start:p.i])
+	if s == "" {
+		return nil, p.errorf("expected value")
+	}
+
+	unsigned := strings.TrimLeft(s, "+-")
+
+	switch unsigned {
+	case "inf":
+		if s[0] == '-' {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+
+	clean := strings.ReplaceAll(s, "_", "")
+
+	if len(unsigned) > 2 && unsigned[0] == '0' && strings.IndexByte("xob", unsigned[1]) != -1 {
+		if s != unsigned || !validTOMLUnderscores(unsigned[2:], isHexDigit) {
+			return nil, p.errorf("invalid number %q", s)
+		}
+		// strconv understands the 0x, 0o and 0b prefixes with base 0.
+		i, err := strconv.ParseInt(clean, 0, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", s)
+		}
+		return i, nil
+	}
+
+	if !validTOMLUnderscores(s, isDigit) {
+		return nil, p.errorf("invalid number %q", s)
+	}
+
+	if len(unsigned) > 1 && unsigned[0] == '0' && unsigned[1] >= '0' && unsigned[1] <= '9' {
+		return nil, p.errorf("leading zero in number %q", s)
+	}
+
+	if strings.ContainsAny(unsigned, ".eE") {
+		f, err := strconv.ParseFloat(clean, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", s)
+		}
+		return f, nil
+	}
+
+	i, err := strconv.ParseInt(clean, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", s)
+	}
+	return i, nil
+}
+
+// validTOMLUnderscores reports whether every underscore in s
+// is between two digits, as TOML requires of numbers.
+func validTOMLUnderscores(s string, isDigit func(byte) bool) bool {
+	for i := range len(s) {
+		if s[i] == '_' && (i == 0 || i == len(s)-1 || !isDigit(s[i-1]) || !isDigit(s[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for p.i < len(p.b) && (p.b[p.i] == ' ' || p.b[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() error {
+	for {
+		p.skipSpace()
+		if p.i == len(p.b) {
+			return nil
+		}
+		switch p.b[p.i] {
+		case '#', '\r', '\n':
+			if err := p.endOfLine(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// endOfLine consumes an optional comment and the end of the line.
+func (p *tomlParser) endOfLine() error {
+
+	p.skipSpace()
+
+	if p.consume('#') {
+		for p.i < len(p.b) && p.b[p.i] != '\n' {
+			p.i++
+		}
+	}
+
+	switch {
+	case p.i == len(p.b):
+		return nil
+	case p.hasPrefix("\r\n"):
+		p.i += 2
+	case p.hasPrefix("\n"):
+		p.i++
+	default:
+		return p.errorf("unexpected character %q", p.b[p.i])
+	}
+
+	p.line++
+	return nil
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if p.i < len(p.b) && p.b[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	return bytes.HasPrefix(p.b[p.i:], []byte(s))
+}
+
+func (p *tomlParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, a...))
+}
+
+// tomlFinish replaces the arrays of tables in t with []any.
+func tomlFinish(t Map[string, any]) {
+	for i, x := range t.s {
+		switch v := x.val.(type) {
+		case Map[string, any]:
+			tomlFinish(v)
+		case []any:
+			for _, elem := range v {
+				if sub, ok := elem.(Map[string, any]); ok {
+					tomlFinish(sub)
+				}
+			}
+		case *tomlTableArray:
+			a := make([]any, len(v.tables))
+			for j, sub := range v.tables {
+				tomlFinish(sub)
+				a[j] = sub
+			}
+			t.s[i].val = a
+		}
+	}
+}
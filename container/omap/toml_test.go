@@ -0,0 +1,271 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func TestTOMLRoundTrip(t *testing.T) {
+
+	const src = `title = "x"
+a.b.c = 1
+a.d = "e"
+inline = { x = 1, y = { z = [1, 2] } }
+
+[server]
+host = "h"
+
+[server.tls]
+on = true
+
+[[products]]
+name = "p1"
+
+[[products]]
+name = "p2"
+tags = ["a", "b"]
+`
+
+	// Tables followed by plain keys in their parent are written inline.
+	const want = `title = "x"
+
+[a]
+b = { c = 1 }
+d = "e"
+
+[inline]
+x = 1
+
+[inline.y]
+z = [1, 2]
+
+[server]
+host = "h"
+
+[server.tls]
+on = true
+
+[[products]]
+name = "p1"
+
+[[products]]
+name = "p2"
+tags = ["a", "b"]
+`
+
+	var m omap.Map[string, any]
+	if err := m.UnmarshalTOML([]byte(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	const wantMap = "omap[title:x a:omap[b:omap[c:1] d:e] inline:omap[x:1 y:omap[z:[1 2]]] " +
+		"server:omap[host:h tls:omap[on:true]] products:[omap[name:p1] omap[name:p2 tags:[a b]]]]"
+	if diff := cmp.Diff(wantMap, m.String()); diff != "" {
+		t.Errorf("decoded map: incorrect result (-want +got):\n%s", diff)
+	}
+
+	got, err := m.MarshalTOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("MarshalTOML: incorrect result (-want +got):\n%s", diff)
+	}
+
+	var back omap.Map[string, any]
+	if err := back.UnmarshalTOML(got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(wantMap, back.String()); diff != "" {
+		t.Errorf("decoded output: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestTOMLDatetimes(t *testing.T) {
+
+	const src = `odt = 1979-05-27T07:32:00-08:00
+odt-space = 1979-05-27 07:32:00Z
+ldt = 1979-05-27T07:32:00.5
+ld = 1979-05-27
+lt = 07:32:00
+`
+
+	var m omap.Map[string, any]
+	if err := m.UnmarshalTOML([]byte(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"odt":       "1979-05-27T07:32:00-08:00",
+		"odt-space": "1979-05-27T07:32:00Z",
+		"ldt":       "1979-05-27T07:32:00.5+00:00 toml-local-datetime",
+		"ld":        "1979-05-27T00:00:00+00:00 toml-local-date",
+		"lt":        "0000-01-01T07:32:00+00:00 toml-local-time",
+	}
+	for k, v := range m.All() {
+		tm, ok := v.(time.Time)
+		if !ok {
+			t.Fatalf("%s decoded as %T, want time.Time", k, v)
+		}
+		got := tm.Format("2006-01-02T15:04:05.999999999Z07:00")
+		if name := tm.Location().String(); strings.HasPrefix(name, "toml-") {
+			got = tm.Format("2006-01-02T15:04:05.999999999-07:00") + " " + name
+		}
+		if got != want[k] {
+			t.Errorf("%s = %s, want %s", k, got, want[k])
+		}
+	}
+
+	got, err := m.MarshalTOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantText := strings.Replace(src, "1979-05-27 07:32:00Z", "1979-05-27T07:32:00Z", 1)
+	if diff := cmp.Diff(wantText, string(got)); diff != "" {
+		t.Errorf("MarshalTOML: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestTOMLNumbers(t *testing.T) {
+
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{"1_000", int64(1000)},
+		{"0xdead_BEEF", int64(0xdeadbeef)},
+		{"0o7_7", int64(0o77)},
+		{"0b1_0", int64(2)},
+		{"1_0.2_5e1_0", 10.25e10},
+		{"0x_1", nil},
+		{"0o_7", nil},
+		{"0b_1", nil},
+		{"0x1_", nil},
+		{"1__0", nil},
+		{"_1", nil},
+		{"1_", nil},
+		{"1_.5", nil},
+		{"1._5", nil},
+		{"1_e5", nil},
+		{"1e_5", nil},
+	}
+
+	for _, tt := range tests {
+		var m omap.Map[string, any]
+		err := m.UnmarshalTOML([]byte("k = " + tt.src))
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: decoding succeeded", tt.src)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		if got, _ := m.Get("k"); got != tt.want {
+			t.Errorf("%s: got %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestTOMLStrings(t *testing.T) {
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`"tab\there \"q\" \u00e9"`, "tab\there \"q\" é"},
+		{`'C:\path'`, `C:\path`},
+		{"\"\"\"\nfirst\nsecond\"\"\"", "first\nsecond"},
+		{"\"\"\"one \\\n    two \\  \r\n\n  three\"\"\"", "one two three"},
+		{"\"\"\"quotes \"\"\"\"\"", `quotes ""`},
+		{"'''\nraw \\n\n'''", "raw \\n\n"},
+	}
+
+	for _, tt := range tests {
+		var m omap.Map[string, string]
+		if err := m.UnmarshalTOML([]byte("k = " + tt.src)); err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		got, _ := m.Get("k")
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.src, got, tt.want)
+		}
+
+		// The encoded string decodes to the same value.
+		b, err := m.MarshalTOML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back omap.Map[string, string]
+		if err := back.UnmarshalTOML(b); err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		if got, _ := back.Get("k"); got != tt.want {
+			t.Errorf("%s: round trip gave %q, want %q", b, got, tt.want)
+		}
+	}
+
+	invalid := []string{
+		`"unterminated`,
+		"\"new\nline\"",
+		`"bad \x escape"`,
+		`"""backslash \ then text"""`,
+		`"backslash \` + "\n" + `in basic string"`,
+		"\"nul \x00\"",
+		"\"escape \x1b[0m\"",
+		"\"del \x7f\"",
+		"\"carriage \r return\"",
+		"\"\"\"bell \a\"\"\"",
+		"\"\"\"lone \r\"\"\"",
+		"'unit \x1f separator'",
+	}
+	for _, src := range invalid {
+		var m omap.Map[string, string]
+		if err := m.UnmarshalTOML([]byte("k = " + src)); err == nil {
+			t.Errorf("%s: decoding succeeded", src)
+		}
+	}
+}
+
+func TestTOMLRedefinition(t *testing.T) {
+
+	invalid := []string{
+		"a = 1\na = 2",
+		"[a]\n[a]",
+		"a.b = 1\n[a]",
+		"[a]\nb = 1\n[a.b]",
+		"a = { x = 1 }\n[a.y]",
+		"a = { x = 1 }\na.y = 2",
+		"a = 1\n[[a]]",
+		"[[a]]\n[a]",
+		"[a.b]\n[a]\nb.c = 1",
+	}
+
+	for _, src := range invalid {
+		var m omap.Map[string, any]
+		err := m.UnmarshalTOML([]byte(src))
+		if err == nil {
+			t.Errorf("%q: decoding succeeded", src)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), "toml: line ") {
+			t.Errorf("%q: error %q doesn't report the line", src, err)
+		}
+	}
+
+	// Defining a table implicitly created by a header is allowed.
+	var m omap.Map[string, any]
+	if err := m.UnmarshalTOML([]byte("[a.b]\nx = 1\n[a]\ny = 2")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the JSON type of a [Value].
+type Kind uint8
+
+const (
+	KindInvalid Kind = iota
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	default:
+		return "invalid"
+	}
+}
+
+// Value holds an arbitrary JSON value.
+//
+// Unlike decoding into any, which turns objects into map[string]any,
+// Value decodes objects into Map[string, any] so their key order
+// is kept, and numbers into [json.Number] so their text is kept.
+// A decoded Value also remembers the text of its strings and keys
+// whose encoding differs from the input, such as "a\/b",
+// and encodes them as they were, until they're modified.
+//
+// Encoding a decoded Value therefore reproduces compact input
+// byte-for-byte. Insignificant whitespace isn't kept:
+// the output is always compact, and indented input is reproduced
+// by passing the output through [json.Indent] with the same indent,
+// if it was formatted like [json.MarshalIndent] does.
+//
+// Note that [json.Marshal] escapes HTML characters
+// in the output of [Value.MarshalJSON];
+// call the method directly or use a [json.Encoder]
+// with HTML escaping disabled to avoid that.
+//
+// V holds one of the following types, recursively:
+// nil, bool, json.Number, string, []any, or Map[string, any].
+// The zero Value is null.
+type Value struct {
+	V any
+
+	src *valueSource
+}
+
+// valueSource holds the text of the strings of a decoded [Value]
+// that encoding wouldn't reproduce, by their location in the value:
+// object keys and values by object, and array elements by address.
+//
+// The decoded strings are kept along with their text,
+// so that strings modified since are encoded normally.
+type valueSource struct {
+	root  rawString
+	keys  map[*omap[string, any]]map[string]string
+	vals  map[*omap[string, any]]map[string]rawString
+	elems map[*any]rawString
+}
+
+// rawString is the text of the decoded string s.
+type rawString struct {
+	s, text string
+}
+
+// textOf returns the text to encode v with,
+// or "" if v isn't the string that r was decoded from.
+func (r rawString) textOf(v any) string {
+	if s, ok := v.(string); ok && s == r.s {
+		return r.text
+	}
+	return ""
+}
+
+func newValueSource() *valueSource {
+	return &valueSource{
+		keys:  make(map[*omap[string, any]]map[string]string),
+		vals:  make(map[*omap[string, any]]map[string]rawString),
+		elems: make(map[*any]rawString),
+	}
+}
+
+func (s *valueSource) key(m Map[string, any], key string) string {
+	if s == nil {
+		return ""
+	}
+	return s.keys[m.omap][key]
+}
+
+func (s *valueSource) val(m Map[string, any], key string) rawString {
+	if s == nil {
+		return rawString{}
+	}
+	return s.vals[m.omap][key]
+}
+
+func (s *valueSource) elem(p *any) rawString {
+	if s == nil {
+		return rawString{}
+	}
+	return s.elems[p]
+}
+
+// setMember records the texts of the key and value of an object member,
+// forgetting the text of a previous value of the key.
+func (s *valueSource) setMember(m Map[string, any], key, keyText string, val rawString) {
+	if keyText != "" {
+		if s.keys[m.omap] == nil {
+			s.keys[m.omap] = make(map[string]string)
+		}
+		s.keys[m.omap][key] = keyText
+	}
+	if val.text != "" {
+		if s.vals[m.omap] == nil {
+			s.vals[m.omap] = make(map[string]rawString)
+		}
+		s.vals[m.omap][key] = val
+	} else {
+		delete(s.vals[m.omap], key)
+	}
+}
+
+func (v Value) Kind() Kind {
+	switch v.V.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case json.Number:
+		return KindNumber
+	case string:
+		return KindString
+	case []any:
+		return KindArray
+	case Map[string, any]:
+		return KindObject
+	default:
+		return KindInvalid
+	}
+}
+
+// Lookup returns the value at the given path,
+// where each element of path is an object key or an array index.
+func (v Value) Lookup(path ...string) (Value, bool) {
+	for _, p := range path {
+		var ok bool
+		v, ok = v.child(p)
+		if !ok {
+			return Value{}, false
+		}
+	}
+	return v, true
+}
+
+// Pointer returns the value referenced by the RFC 6901 JSON pointer ptr,
+// e.g. "/servers/0/name".
+func (v Value) Pointer(ptr string) (Value, bool) {
+	path, err := parsePointer(ptr)
+	if err != nil {
+		return Value{}, false
+	}
+	return v.Lookup(path...)
+}
+
+// AsString returns the string held by v, if it holds one.
+func (v Value) AsString() (string, bool) {
+	s, ok := v.V.(string)
+	return s, ok
+}
+
+func (v Value) child(p string) (Value, bool) {
+	switch x := v.V.(type) {
+	case Map[string, any]:
+		c, ok := x.Get(p)
+		return Value{c, v.src}, ok
+	case []any:
+		i, ok := arrayIndex(p, len(x))
+		if !ok {
+			return Value{}, false
+		}
+		return Value{x[i], v.src}, true
+	default:
+		return Value{}, false
+	}
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	e := newValueEncoder(v.src)
+	var root rawString
+	if v.src != nil {
+		root = v.src.root
+	}
+	if err := e.encode(v.V, root); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+func (v *Value) UnmarshalJSON(b []byte) error {
+	d := &valueDecoder{
+		dec: json.NewDecoder(bytes.NewReader(b)),
+		in:  b,
+		enc: newValueEncoder(nil),
+		src: newValueSource(),
+	}
+	d.dec.UseNumber()
+	x, raw, err := d.decode()
+	if err != nil {
+		return err
+	}
+	d.src.root = raw
+	v.V = x
+	v.src = d.src
+	return nil
+}
+
+// clone returns a deep copy of v, along with the text of its strings.
+func (v Value) clone() Value {
+	if v.src == nil {
+		return Value{cloneValue(v.V), nil}
+	}
+	src := newValueSource()
+	src.root = v.src.root
+	return Value{cloneSource(v.V, v.src, src), src}
+}
+
+type valueDecoder struct {
+	dec *json.Decoder
+	in  []byte
+	enc *valueEncoder // enc encodes strings to compare them with their text.
+	src *valueSource
+}
+
+// decode decodes the next value,
+// along with its text if it's a string that encoding wouldn't reproduce.
+func (d *valueDecoder) decode() (any, rawString, error) {
+
+	t, raw, err := d.token()
+	if err != nil {
+		return nil, raw, err
+	}
+
+	delim, ok := t.(json.Delim)
+	if !ok {
+		return t, raw, nil
+	}
+
+	switch delim {
+
+	case '[':
+		a := []any{}
+		var raws map[int]rawString
+		for d.dec.More() {
+			x, elemRaw, err := d.decode()
+			if err != nil {
+				return nil, raw, err
+			}
+			if elemRaw.text != "" {
+				if raws == nil {
+					raws = make(map[int]rawString)
+				}
+				raws[len(a)] = elemRaw
+			}
+			a = append(a, x)
+		}
+		if _, err := d.dec.Token(); err != nil {
+			return nil, raw, err
+		}
+		// The elements have their final addresses
+		// once the array is complete.
+		for i, elemRaw := range raws {
+			d.src.elems[&a[i]] = elemRaw
+		}
+		return a, raw, nil
+
+	case '{':
+		m := New[string, any]()
+		for d.dec.More() {
+			t, keyRaw, err := d.token()
+			if err != nil {
+				return nil, raw, err
+			}
+			key, ok := t.(string)
+			if !ok {
+				return nil, raw, fmt.Errorf("expected string key, got %T", t)
+			}
+			x, valRaw, err := d.decode()
+			if err != nil {
+				return nil, raw, err
+			}
+			m.Set(key, x)
+			d.src.setMember(m, key, keyRaw.text, valRaw)
+		}
+		if _, err := d.dec.Token(); err != nil {
+			return nil, raw, err
+		}
+		return m, raw, nil
+
+	default:
+		return nil, raw, fmt.Errorf("unexpected delimiter %v", delim)
+	}
+}
+
+// token reads the next token, along with its text if it's a string
+// that encoding wouldn't reproduce.
+func (d *valueDecoder) token() (t json.Token, raw rawString, err error) {
+
+	start := d.dec.InputOffset()
+	t, err = d.dec.Token()
+	if err != nil {
+		return nil, raw, err
+	}
+
+	s, ok := t.(string)
+	if !ok {
+		return t, raw, nil
+	}
+
+	// Only whitespace, colons and commas precede the opening quote.
+	text := d.in[start:d.dec.InputOffset()]
+	text = text[bytes.IndexByte(text, '"'):]
+
+	d.enc.buf.Reset()
+	if err := d.enc.encode(s, raw); err != nil {
+		return nil, raw, err
+	}
+	if bytes.Equal(text, d.enc.buf.Bytes()) {
+		return t, raw, nil
+	}
+
+	return t, rawString{s, string(text)}, nil
+}
+
+type valueEncoder struct {
+	buf *bytes.Buffer
+	enc *json.Encoder
+	src *valueSource
+}
+
+func newValueEncoder(src *valueSource) *valueEncoder {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &valueEncoder{buf, enc, src}
+}
+
+// encode encodes v, using the text of raw
+// if v is the string that raw was decoded from.
+func (e *valueEncoder) encode(v any, raw rawString) error {
+
+	if text := raw.textOf(v); text != "" {
+		e.buf.WriteString(text)
+		return nil
+	}
+
+	switch v := v.(type) {
+
+	case nil:
+		e.buf.WriteString("null")
+
+	case bool:
+		e.buf.WriteString(strconv.FormatBool(v))
+
+	case json.Number:
+		e.buf.WriteString(v.String())
+
+	case []any:
+		e.buf.WriteByte('[')
+		for i := range v {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.encode(v[i], e.src.elem(&v[i])); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+
+	case Map[string, any]:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		e.buf.WriteByte('{')
+		for i, t := range v.s {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if text := e.src.key(v, t.key); text != "" {
+				e.buf.WriteString(text)
+			} else if err := e.encode(t.key, rawString{}); err != nil {
+				return err
+			}
+			e.buf.WriteByte(':')
+			if err := e.encode(t.val, e.src.val(v, t.key)); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte('}')
+
+	default:
+		// Encoder.Encode appends a newline
+		// after each value; drop it.
+		if err := e.enc.Encode(v); err != nil {
+			return err
+		}
+		e.buf.Truncate(e.buf.Len() - 1)
+	}
+
+	return nil
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens.
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("json pointer %q does not start with '/'", ptr)
+	}
+	path := strings.Split(ptr[1:], "/")
+	for i, p := range path {
+		p = strings.ReplaceAll(p, "~1", "/")
+		p = strings.ReplaceAll(p, "~0", "~")
+		path[i] = p
+	}
+	return path, nil
+}
+
+// arrayIndex parses p as an index into an array of length n.
+func arrayIndex(p string, n int) (int, bool) {
+	if p == "" || (len(p) > 1 && p[0] == '0') {
+		return 0, false
+	}
+	i, err := strconv.ParseUint(p, 10, 0)
+	if err != nil || i >= uint64(n) {
+		return 0, false
+	}
+	return int(i), true
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+)
+
+func TestValueRoundTrip(t *testing.T) {
+
+	inputs := []string{
+		`{"b":1,"a":[true,null,"x"],"c":{"z":1.50,"y":1e3}}`,
+		`{"b":"a\/b","é":"é","s":"<&>","u":"😀"}`,
+		`["tab\there","quote\"","uni "]`,
+		`"top\/level"`,
+		`12.0`,
+		`{}`,
+		`[]`,
+	}
+
+	for _, in := range inputs {
+		var v omap.Value
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		got, err := v.MarshalJSON()
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if diff := cmp.Diff(in, string(got)); diff != "" {
+			t.Errorf("re-encoding: incorrect result (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestValueStrings(t *testing.T) {
+
+	const in = `{"a":"x\/y","b":"plain","c\u0041":["\u00e9","e"],"d":"\u00e9"}`
+
+	var v omap.Value
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	// Strings decode to string whatever their escaping.
+	for _, ptr := range []string{"/a", "/b", "/cA/0", "/cA/1"} {
+		x, _ := v.Pointer(ptr)
+		if _, ok := x.V.(string); !ok {
+			t.Errorf("%s decoded as %T, want string", ptr, x.V)
+		}
+	}
+	if s, ok := v.Pointer("/a"); !ok || s.V != "x/y" {
+		t.Errorf("/a = %q, want %q", s.V, "x/y")
+	}
+
+	check := func(title string, v omap.Value, want string) {
+		t.Helper()
+		got, err := v.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s: incorrect result (-want +got):\n%s", title, diff)
+		}
+	}
+
+	// Looked up values keep the text of their strings.
+	c, _ := v.Pointer("/cA")
+	check("lookup", c, `["\u00e9","e"]`)
+
+	// Modified strings are encoded normally,
+	// and the others keep their text.
+	m := v.V.(omap.Map[string, any])
+	m.Set("a", "x/z")
+	m.Set("b", `new\/`)
+	c.V.([]any)[1] = `\u00e9`
+	m.Set("d", "é")
+	check("edited", v, `{"a":"x/z","b":"new\\/","c\u0041":["\u00e9","\\u00e9"],"d":"\u00e9"}`)
+
+	m.Set("d", "f")
+	check("edited again", v, `{"a":"x/z","b":"new\\/","c\u0041":["\u00e9","\\u00e9"],"d":"f"}`)
+
+	// Maps don't hold the texts of the strings of a Value.
+	got, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`{"a":"x/z","b":"new\\/","cA":["é","\\u00e9"],"d":"f"}`, string(got)); diff != "" {
+		t.Errorf("json.Marshal of the map: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestValueEditing(t *testing.T) {
+
+	const in = `{"a":"x\/y","b":["\u00e9",1],"c":{"d\/":"\t"}}`
+
+	doc := mustValue(t, in)
+
+	var p omap.Patch
+	if err := json.Unmarshal([]byte(`[{"op":"replace","path":"/b/1","value":2}]`), &p); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Apply(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`{"a":"x\/y","b":["\u00e9",2],"c":{"d\/":"\t"}}`, valueJSON(t, got)); diff != "" {
+		t.Errorf("Apply: incorrect result (-want +got):\n%s", diff)
+	}
+
+	merged := omap.MergePatch(doc, mustValue(t, `{"e":"/"}`))
+	if diff := cmp.Diff(`{"a":"x\/y","b":["\u00e9",1],"c":{"d\/":"\t"},"e":"/"}`, valueJSON(t, merged)); diff != "" {
+		t.Errorf("MergePatch: incorrect result (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(in, valueJSON(t, doc)); diff != "" {
+		t.Errorf("document: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestValueIndented(t *testing.T) {
+
+	// Indented input comes back through json.Indent
+	// if it was formatted like json.MarshalIndent does.
+	const in = `{
+  "b": [
+    1,
+    "x\/y"
+  ],
+  "a": {},
+  "c": []
+}`
+
+	v := mustValue(t, in)
+	var got bytes.Buffer
+	if err := json.Indent(&got, []byte(valueJSON(t, v)), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, got.String()); diff != "" {
+		t.Errorf("re-indented: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func TestValueLookup(t *testing.T) {
+
+	var v omap.Value
+	in := `{"servers":[{"name":"a"},{"name":"b"}],"a/b":{"c~d":1},"":2}`
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ptr  string
+		path []string
+		want any
+		ok   bool
+	}{
+		{"/servers/1/name", []string{"servers", "1", "name"}, "b", true},
+		{"/a~1b/c~0d", []string{"a/b", "c~d"}, json.Number("1"), true},
+		{"/", []string{""}, json.Number("2"), true},
+		{"/servers/01", []string{"servers", "01"}, nil, false},
+		{"/servers/2", []string{"servers", "2"}, nil, false},
+		{"/servers/-1", []string{"servers", "-1"}, nil, false},
+		{"/servers/0/name/x", []string{"servers", "0", "name", "x"}, nil, false},
+		{"/missing", []string{"missing"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := v.Pointer(tt.ptr)
+		if ok != tt.ok || !cmp.Equal(tt.want, got.V) {
+			t.Errorf("Pointer(%q) = %v, %v; want %v, %v", tt.ptr, got.V, ok, tt.want, tt.ok)
+		}
+		got, ok = v.Lookup(tt.path...)
+		if ok != tt.ok || !cmp.Equal(tt.want, got.V) {
+			t.Errorf("Lookup(%q) = %v, %v; want %v, %v", tt.path, got.V, ok, tt.want, tt.ok)
+		}
+	}
+
+	if root, ok := v.Pointer(""); !ok || root.Kind() != omap.KindObject {
+		t.Errorf("Pointer(\"\") = %v, %v; want the root object", root.Kind(), ok)
+	}
+	if _, ok := v.Pointer("servers"); ok {
+		t.Error("Pointer without leading slash succeeded")
+	}
+
+	kinds := map[string]omap.Kind{
+		"null": omap.KindNull, "true": omap.KindBool, "1": omap.KindNumber,
+		`"s"`: omap.KindString, "[]": omap.KindArray, "{}": omap.KindObject,
+	}
+	for in, want := range kinds {
+		var v omap.Value
+		json.Unmarshal([]byte(in), &v)
+		if v.Kind() != want {
+			t.Errorf("Kind of %s = %v, want %v", in, v.Kind(), want)
+		}
+	}
+}
@@ -5,10 +5,68 @@ package omap
 
 import (
 	"fmt"
+	"reflect"
+	"slices"
 
 	"go.yaml.in/yaml/v4"
 )
 
+// yamlSource holds the YAML nodes a map was decoded from,
+// so that re-encoding it preserves comments and styles.
+type yamlSource[K comparable] struct {
+	node  *yaml.Node // The mapping node, without its content.
+	nodes map[K][2]*yaml.Node
+}
+
+// clone returns a deep copy of s, or nil if s is nil.
+func (s *yamlSource[K]) clone() *yamlSource[K] {
+	if s == nil {
+		return nil
+	}
+	copies := make(map[*yaml.Node]*yaml.Node)
+	c := &yamlSource[K]{
+		node:  deepCopyYAMLNode(s.node, copies),
+		nodes: make(map[K][2]*yaml.Node, len(s.nodes)),
+	}
+	for k, n := range s.nodes {
+		c.nodes[k] = [2]*yaml.Node{
+			deepCopyYAMLNode(n[0], copies),
+			deepCopyYAMLNode(n[1], copies),
+		}
+	}
+	return c
+}
+
+// deepCopyYAMLNode returns a deep copy of node,
+// in which aliases refer to the copies of their anchored nodes.
+// copies maps the nodes copied so far to their copies.
+func deepCopyYAMLNode(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if c, ok := copies[node]; ok {
+		return c
+	}
+	c := new(yaml.Node)
+	*c = *node
+	copies[node] = c
+	c.Alias = deepCopyYAMLNode(node.Alias, copies)
+	if node.Content != nil {
+		c.Content = make([]*yaml.Node, len(node.Content))
+		for i, n := range node.Content {
+			c.Content[i] = deepCopyYAMLNode(n, copies)
+		}
+	}
+	return c
+}
+
+// MarshalYAML encodes the map as a YAML mapping node.
+//
+// If the map was decoded from YAML, the original nodes are reused
+// for entries whose keys and values haven't changed since,
+// preserving their comments, anchors, styles and quoting.
+// Changed values keep the comments of the original value.
+// New entries are encoded from scratch.
 func (m Map[K, V]) MarshalYAML() (any, error) {
 
 	node := &yaml.Node{
@@ -19,30 +77,118 @@ func (m Map[K, V]) MarshalYAML() (any, error) {
 		return node, nil
 	}
 
-	for _, t := range m.s {
+	src := m.yaml
+	if src != nil {
+		*node = *src.node
+	}
 
-		key := &yaml.Node{}
-		val := &yaml.Node{}
+	for _, t := range m.s {
 
-		err := key.Encode(t.key)
+		key, err := encodeYAMLNode(t.key)
 		if err != nil {
 			return nil, err
 		}
 
-		err = val.Encode(t.val)
+		val, err := encodeYAMLNode(t.val)
 		if err != nil {
 			return nil, err
 		}
 
+		if src != nil {
+			if orig, ok := src.nodes[t.key]; ok {
+				key = reuseYAMLNode(orig[0], key)
+				val = reuseYAMLNode(orig[1], val)
+			}
+		}
+
 		node.Content = append(node.Content, key, val)
 	}
 
+	// Reused nodes may refer to anchors of entries
+	// that were deleted or changed since.
+	defined := make(map[*yaml.Node]bool)
+	for i, c := range node.Content {
+		node.Content[i] = resolveYAMLAliases(c, defined)
+	}
+
 	return node, nil
 }
 
 func (m *Map[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	return DecodeYAML(node, m, LastWins)
+}
+
+// DecodeYAML decodes the YAML mapping node into m,
+// handling duplicate keys according to policy.
+//
+// The nodes of the decoded entries are retained
+// for [Map.MarshalYAML] to reuse.
+//
+// [Map.UnmarshalYAML] is equivalent to DecodeYAML with [LastWins].
+func DecodeYAML[K comparable, V any](node *yaml.Node, m *Map[K, V], policy DuplicatePolicy) error {
 
 	m.init()
+	node = unwrapYAMLDocument(node)
+
+	src := &yamlSource[K]{
+		node:  new(yaml.Node),
+		nodes: make(map[K][2]*yaml.Node),
+	}
+	*src.node = *node
+	src.node.Content = nil
+
+	seen := make(map[K]struct{})
+
+	err := decodeYAMLMapping(node, func(key K, val V, keyNode, valNode *yaml.Node) error {
+		_, dup := seen[key]
+		if !m.decodeSet(key, val, policy, seen) {
+			return &DuplicateKeyError{
+				Key:    keyNode.Value,
+				Line:   keyNode.Line,
+				Column: keyNode.Column,
+				Offset: -1,
+			}
+		}
+		if !dup || policy != FirstWins {
+			src.nodes[key] = [2]*yaml.Node{keyNode, valNode}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if m.yaml != nil {
+		for k, v := range m.yaml.nodes {
+			if _, ok := src.nodes[k]; !ok {
+				src.nodes[k] = v
+			}
+		}
+	}
+	m.yaml = src
+
+	return nil
+}
+
+// DecodeYAMLAll decodes the YAML mapping node into m,
+// keeping the values of duplicate keys in the order they appear.
+func DecodeYAMLAll[K comparable, V any](node *yaml.Node, m *Map[K, []V]) error {
+	m.init()
+	return decodeYAMLMapping(node, func(key K, val V, _, _ *yaml.Node) error {
+		vals, _ := m.Get(key)
+		m.Set(key, append(vals, val))
+		return nil
+	})
+}
+
+// decodeYAMLMapping calls fn for each key-value pair of the mapping node,
+// along with the nodes of the key and the value.
+func decodeYAMLMapping[K, V any](
+	node *yaml.Node,
+	fn func(key K, val V, keyNode, valNode *yaml.Node) error,
+) error {
+
+	node = unwrapYAMLDocument(node)
 
 	if node.Kind != yaml.MappingNode {
 		return fmt.Errorf("expected yaml mapping node, got %v", node.Kind)
@@ -63,8 +209,158 @@ func (m *Map[K, V]) UnmarshalYAML(node *yaml.Node) error {
 			return err
 		}
 
-		m.Set(key, val)
+		if err := fn(key, val, node.Content[i], node.Content[i+1]); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// encodeYAMLNode encodes v into a node.
+//
+// [yaml.Node.Encode] renders v to text and parses it back,
+// which drops the comments of nodes returned by [yaml.Marshaler]s
+// such as nested maps, so those nodes are used directly.
+func encodeYAMLNode(v any) (*yaml.Node, error) {
+	if m, ok := v.(yaml.Marshaler); ok {
+		x, err := m.MarshalYAML()
+		if err != nil {
+			return nil, err
+		}
+		if node, ok := x.(*yaml.Node); ok {
+			return node, nil
+		}
+		v = x
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// unwrapYAMLDocument returns the content of a document node,
+// moving the document comments onto it.
+func unwrapYAMLDocument(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.DocumentNode || len(node.Content) != 1 {
+		return node
+	}
+	c := node.Content[0]
+	if c.HeadComment == "" {
+		c.HeadComment = node.HeadComment
+	}
+	if c.FootComment == "" {
+		c.FootComment = node.FootComment
+	}
+	return c
+}
+
+// reuseYAMLNode returns orig if it's equivalent to the freshly encoded
+// node, and otherwise fresh with the comments and, for strings,
+// the quoting style of orig.
+func reuseYAMLNode(orig, fresh *yaml.Node) *yaml.Node {
+
+	if equalYAMLNodes(orig, fresh) {
+		return orig
+	}
+
+	fresh.HeadComment = orig.HeadComment
+	fresh.LineComment = orig.LineComment
+	fresh.FootComment = orig.FootComment
+
+	if orig.Kind == yaml.ScalarNode && fresh.Kind == yaml.ScalarNode &&
+		orig.ShortTag() == "!!str" && fresh.ShortTag() == "!!str" {
+		fresh.Style = orig.Style
+	}
+
+	return fresh
+}
+
+// resolveYAMLAliases returns node, or a copy of it in which
+// the aliases to anchors not defined before them in the output
+// are replaced by copies of the nodes they refer to.
+// defined holds the anchored nodes output so far.
+//
+// Nested maps are resolved on their own, so their aliases
+// to anchors outside of them are always replaced.
+func resolveYAMLAliases(node *yaml.Node, defined map[*yaml.Node]bool) *yaml.Node {
+
+	if node.Kind == yaml.AliasNode {
+		if defined[node.Alias] {
+			return node
+		}
+		return resolveYAMLAliases(copyYAMLNode(node.Alias), defined)
+	}
+
+	if node.Anchor != "" {
+		defined[node] = true
+	}
+
+	var content []*yaml.Node
+	for i, c := range node.Content {
+		r := resolveYAMLAliases(c, defined)
+		if r != c && content == nil {
+			content = slices.Clone(node.Content)
+		}
+		if content != nil {
+			content[i] = r
+		}
+	}
+
+	if content == nil {
+		return node
+	}
+
+	n := *node
+	n.Content = content
+	return &n
+}
+
+// copyYAMLNode returns a deep copy of node without its anchors.
+func copyYAMLNode(node *yaml.Node) *yaml.Node {
+	n := *node
+	n.Anchor = ""
+	n.Content = make([]*yaml.Node, len(node.Content))
+	for i, c := range node.Content {
+		n.Content[i] = copyYAMLNode(c)
+	}
+	return &n
+}
+
+// equalYAMLNodes reports whether a and b represent the same data,
+// regardless of style, comments and aliasing.
+func equalYAMLNodes(a, b *yaml.Node) bool {
+
+	for a.Kind == yaml.AliasNode {
+		a = a.Alias
+	}
+	for b.Kind == yaml.AliasNode {
+		b = b.Alias
+	}
+
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || len(a.Content) != len(b.Content) {
+		return false
+	}
+
+	if a.Kind == yaml.ScalarNode {
+		if a.Value == b.Value {
+			return true
+		}
+		// Different representations of the same value,
+		// e.g. 0x10 and 16.
+		var x, y any
+		if a.Decode(&x) != nil || b.Decode(&y) != nil {
+			return false
+		}
+		return reflect.DeepEqual(x, y)
+	}
+
+	for i := range a.Content {
+		if !equalYAMLNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+
+	return true
+}
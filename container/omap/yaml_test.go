@@ -0,0 +1,177 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package omap_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/omap"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+
+	const src = `# head
+a: 'quoted' # line
+b: 0x10
+c: [1, 2]
+`
+
+	tests := []struct {
+		name string
+		edit func(m *omap.Map[string, any])
+		want string
+	}{
+		{
+			"untouched",
+			func(m *omap.Map[string, any]) {},
+			src,
+		},
+		{
+			"edited",
+			func(m *omap.Map[string, any]) { m.Set("a", "changed") },
+			"# head\na: 'changed' # line\nb: 0x10\nc: [1, 2]\n",
+		},
+		{
+			"same value",
+			func(m *omap.Map[string, any]) { m.Set("b", 16) },
+			src,
+		},
+		{
+			"new",
+			func(m *omap.Map[string, any]) { m.Set("d", "new") },
+			src + "d: new\n",
+		},
+		{
+			"deleted",
+			func(m *omap.Map[string, any]) { m.Delete("b") },
+			"# head\na: 'quoted' # line\nc: [1, 2]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m omap.Map[string, any]
+			if err := yaml.Unmarshal([]byte(src), &m); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(&m)
+			got, err := yaml.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("yaml.Marshal: incorrect result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestYAMLAliases(t *testing.T) {
+
+	const src = "a: &anc {x: 1}\nb: 2\nc: *anc\n"
+
+	tests := []struct {
+		name string
+		edit func(m *omap.Map[string, any])
+		want string
+	}{
+		{
+			"untouched",
+			func(m *omap.Map[string, any]) {},
+			src,
+		},
+		{
+			"anchor deleted",
+			func(m *omap.Map[string, any]) { m.Delete("a") },
+			"b: 2\nc: {x: 1}\n",
+		},
+		{
+			"anchor changed",
+			func(m *omap.Map[string, any]) { m.Set("a", 3) },
+			"a: 3\nb: 2\nc: {x: 1}\n",
+		},
+		{
+			"anchor moved after alias",
+			func(m *omap.Map[string, any]) {
+				v, _ := m.Delete("a")
+				m.Set("a", v)
+			},
+			"b: 2\nc: {x: 1}\na: &anc {x: 1}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var m omap.Map[string, any]
+			if err := yaml.Unmarshal([]byte(src), &m); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(&m)
+
+			got, err := yaml.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("yaml.Marshal: incorrect result (-want +got):\n%s", diff)
+			}
+
+			// Marshaling the map twice gives the same result.
+			again, _ := yaml.Marshal(m)
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				t.Errorf("second yaml.Marshal: incorrect result (-want +got):\n%s", diff)
+			}
+
+			var back omap.Map[string, any]
+			if err := yaml.Unmarshal(got, &back); err != nil {
+				t.Fatalf("yaml.Unmarshal of the output: %v", err)
+			}
+		})
+	}
+}
+
+func TestYAMLClone(t *testing.T) {
+
+	const src = "# head\na: &anc x # line\nb: 2\nc: *anc\n"
+
+	var m omap.Map[string, any]
+	if err := yaml.Unmarshal([]byte(src), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	c := m.Clone()
+	c.Set("b", 3)
+
+	x, err := c.MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := x.(*yaml.Node)
+
+	got, err := yaml.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("# head\na: &anc x # line\nb: 3\nc: *anc\n", string(got)); diff != "" {
+		t.Errorf("yaml.Marshal of the clone: incorrect result (-want +got):\n%s", diff)
+	}
+
+	// Editing the nodes of the clone leaves the original unchanged.
+	node.HeadComment = "edited"
+	for _, n := range node.Content {
+		n.LineComment = "edited"
+		n.Value += "!"
+	}
+
+	got, err = yaml.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(src, string(got)); diff != "" {
+		t.Errorf("yaml.Marshal of the original: incorrect result (-want +got):\n%s", diff)
+	}
+}
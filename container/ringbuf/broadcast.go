@@ -0,0 +1,178 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Broadcast is a goroutine-safe ring buffer
+// whose data is read by any number of independent readers.
+//
+// Writes never block: like [Buffer], a Broadcast retains
+// the last maxLen elements written to it, regardless of the readers,
+// and readers that fall behind miss the overwritten elements.
+type Broadcast[T any] struct {
+	mu      sync.Mutex
+	buf     *Buffer[T]
+	err     error
+	closed  bool
+	changed chan struct{} // changed is closed and replaced when data is written or the broadcast is closed.
+}
+
+// NewBroadcast returns a new broadcast retaining up to maxLen elements.
+//
+// It panics if maxLen isn't positive.
+func NewBroadcast[T any](maxLen int) *Broadcast[T] {
+	if maxLen <= 0 {
+		panic("ringbuf.NewBroadcast: maxLen <= 0")
+	}
+	return &Broadcast[T]{
+		buf:     NewBuffer[T](maxLen),
+		changed: make(chan struct{}),
+	}
+}
+
+// Write writes src for all the readers.
+// Writing to a closed broadcast fails with [io.ErrClosedPipe].
+func (b *Broadcast[T]) Write(src []T) (int, error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	if len(src) > 0 {
+		b.buf.Write(src)
+		b.notify()
+	}
+
+	return len(src), nil
+}
+
+// Close closes the broadcast; see [Broadcast.CloseWithError].
+func (b *Broadcast[T]) Close() error {
+	return b.CloseWithError(nil)
+}
+
+// CloseWithError closes the broadcast.
+// Subsequent writes fail with [io.ErrClosedPipe],
+// and readers return the remaining data and then err,
+// or [io.EOF] if err is nil.
+//
+// Closing an already closed broadcast has no effect.
+func (b *Broadcast[T]) CloseWithError(err error) error {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	if err == nil {
+		err = io.EOF
+	}
+
+	b.closed = true
+	b.err = err
+	b.notify()
+
+	return nil
+}
+
+// NewReader returns a new reader of the broadcast,
+// starting at the oldest element it retains.
+func (b *Broadcast[T]) NewReader() *BroadcastReader[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &BroadcastReader[T]{
+		b:   b,
+		pos: b.start(),
+	}
+}
+
+// start returns the position of the oldest retained element
+// in the stream of written elements.
+func (b *Broadcast[T]) start() uint64 {
+	return b.buf.Written() - uint64(b.buf.Len())
+}
+
+func (b *Broadcast[T]) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// BroadcastReader is a reader of a [Broadcast] with its own cursor.
+//
+// Different readers can be used concurrently,
+// but a single reader must not.
+type BroadcastReader[T any] struct {
+	b   *Broadcast[T]
+	pos uint64 // pos is the position of the cursor in the stream of written elements.
+}
+
+// Read reads the next elements of the broadcast,
+// blocking until there are some or the broadcast is closed.
+//
+// If the reader fell behind and elements were overwritten
+// before it read them, Read reads nothing and returns
+// an [*OverrunError] with the number of missed elements,
+// and the next read resumes at the oldest retained element.
+func (r *BroadcastReader[T]) Read(dest []T) (int, error) {
+	return r.ReadContext(context.Background(), dest)
+}
+
+// ReadContext is like [BroadcastReader.Read],
+// but stops waiting for data when ctx is done.
+func (r *BroadcastReader[T]) ReadContext(ctx context.Context, dest []T) (int, error) {
+
+	b := r.b
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(dest) == 0 {
+		return 0, nil
+	}
+
+	for r.pos == b.buf.Written() {
+
+		if b.closed {
+			return 0, b.err
+		}
+
+		changed := b.changed
+		b.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			b.mu.Lock()
+			return 0, context.Cause(ctx)
+		}
+		b.mu.Lock()
+	}
+
+	if start := b.start(); r.pos < start {
+		err := &OverrunError{Dropped: start - r.pos}
+		r.pos = start
+		return 0, err
+	}
+
+	n, _ := b.buf.ReadAt(dest, int64(r.pos-b.start()))
+	r.pos += uint64(n)
+	return n, nil
+}
+
+// Len returns the number of elements the reader has yet to read,
+// not counting the missed ones.
+func (r *BroadcastReader[T]) Len() int {
+	r.b.mu.Lock()
+	defer r.b.mu.Unlock()
+	return int(r.b.buf.Written() - max(r.pos, r.b.start()))
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestBroadcast(t *testing.T) {
+
+	b := ringbuf.NewBroadcast[byte](64)
+	want := bytes.Repeat([]byte("0123456789"), 10)
+
+	readers := make([]*ringbuf.BroadcastReader[byte], 3)
+	for i := range readers {
+		readers[i] = b.NewReader()
+	}
+
+	got := make([][]byte, len(readers))
+	var wg sync.WaitGroup
+	for i, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], _ = io.ReadAll(r)
+		}()
+	}
+
+	// Write no faster than the slowest reader reads,
+	// so that no reader misses data.
+	for i := 0; i < len(want); i += 10 {
+		b.Write(want[i : i+10])
+		for _, r := range readers {
+			for r.Len() > 0 {
+				runtime.Gosched()
+			}
+		}
+	}
+	b.Close()
+	wg.Wait()
+
+	for i := range readers {
+		diff(t, "io.ReadAll", want, got[i])
+	}
+}
+
+func TestBroadcastOverrun(t *testing.T) {
+
+	b := ringbuf.NewBroadcast[byte](4)
+	b.Write([]byte("ab"))
+
+	r1 := b.NewReader()
+	r2 := b.NewReader()
+
+	buf := make([]byte, 4)
+	n, _ := r1.Read(buf)
+	diff(t, "r1.Read", "ab", buf[:n])
+
+	b.Write([]byte("cdefg"))
+
+	_, err := r2.Read(buf)
+	var overrun *ringbuf.OverrunError
+	if !errors.As(err, &overrun) || overrun.Dropped != 3 {
+		t.Fatalf("r2.Read: want OverrunError of 3 elements, got %v", err)
+	}
+
+	n, _ = r2.Read(buf)
+	diff(t, "r2.Read", "defg", buf[:n])
+
+	_, err = r1.Read(buf)
+	if !errors.As(err, &overrun) || overrun.Dropped != 1 {
+		t.Fatalf("r1.Read: want OverrunError of 1 element, got %v", err)
+	}
+
+	b.Close()
+	n, _ = r1.Read(buf)
+	diff(t, "r1.Read", "defg", buf[:n])
+	if _, err := r1.Read(buf); err != io.EOF {
+		t.Fatalf("r1.Read after close: got %v, want io.EOF", err)
+	}
+}
@@ -6,27 +6,12 @@ package ringbuf
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"iter"
 	"slices"
 )
 
-// TODO:
-//
-//   - Add a Truncate() method.
-//       Of course if cap hasn't been reached, truncation is super easy.
-//       Otherwise, if the buffer ain't that full,
-//       we can shift things around in the buffer itself.
-//       If it's really full, put the extra shit
-//       in a lil buffer you got from a pool.
-//
-//   - Add the ability to lower the cap of the buffer.
-//       of course if the new cap(r.buf) is less than len(r.buf),
-//       we need to jettison SOME data.
-//       Do we jettison the newest data? The oldest data?
-//
-//   - Add Seek().
-
 var ErrNegativeOffset = errors.New("negative offset")
 
 type Buffer[T any] struct {
@@ -35,6 +20,12 @@ type Buffer[T any] struct {
 	writePos int // writePos is an actual position in buf.
 	readPos  int // readPos is relative to writePos. It wraps around buf.
 	byteBuf  [1]T
+
+	written        uint64
+	dropped        uint64
+	overrun        uint64 // overrun is the number of elements dropped since the last report.
+	reportOverruns bool
+	onOverwrite    func(dropped []T)
 }
 
 // NewBuffer returns a new ring buffer.
@@ -60,6 +51,9 @@ func (b *Buffer[T]) Write(src []T) (n int, err error) {
 		return n, nil
 	}
 
+	b.written += uint64(len(src))
+	b.overwrite(len(b.buf)+len(src)-b.maxLen, src)
+
 	if len(src) >= b.maxLen {
 		b.writePos = 0
 		b.buf = slices.Grow(b.buf, b.rem())[:b.maxLen]
@@ -90,7 +84,17 @@ func (b *Buffer[T]) WriteByte(v T) error {
 	return nil
 }
 
+// Read reads unread elements of the buffer into dest.
+//
+// If overrun reporting is enabled with [Buffer.ReportOverruns],
+// and unread elements have been dropped since the last call,
+// Read reads nothing and returns an [*OverrunError].
 func (b *Buffer[T]) Read(dest []T) (int, error) {
+	if b.overrun > 0 {
+		err := &OverrunError{Dropped: b.overrun}
+		b.overrun = 0
+		return 0, err
+	}
 	n, err := b.ReadAt(dest, int64(b.readPos))
 	b.readPos += n
 	return n, err
@@ -145,7 +149,8 @@ func (b *Buffer[T]) BytesSeq() iter.Seq[[]T] {
 // consider using [Buffer.NextSeq].
 func (b *Buffer[T]) Next(n int) []T {
 	s := make([]T, min(n, b.Len()))
-	b.Read(s)
+	b.ReadAt(s, int64(b.readPos))
+	b.readPos += len(s)
 	return s
 }
 
@@ -159,17 +164,18 @@ func (b *Buffer[T]) NextSeq(n int) iter.Seq[[]T] {
 		panic("ringbuf.Buffer.NextSeq: n < 0")
 	}
 	return func(yield func([]T) bool) {
+		read := 0
 		for s := range b.seq(b.readPos) {
-			if n == 0 {
+			if read == n {
 				break
 			}
-			s = s[:min(len(s), n)]
+			s = s[:min(len(s), n-read)]
+			read += len(s)
 			if !yield(s) {
 				break
 			}
-			n -= len(s)
 		}
-		b.readPos += n
+		b.readPos += read
 	}
 }
 
@@ -194,36 +200,229 @@ func (b *Buffer[T]) seq(offset int) iter.Seq[[]T] {
 
 		offset = max(offset-len(s), 0)
 		s = b.buf[offset:b.writePos]
-		if len(s) > 0 && !yield(s[offset:]) {
+		if len(s) > 0 && !yield(s) {
 			return
 		}
 	}
 }
 
-// // Truncate discards all but the first unread bytes from the buffer
-// // but continues to use the same allocated storage.
-// //
-// // It panics if n is negative or greater than r.Len().
-// func (r *Buffer[T]) Truncate(n int) {
-// 	if n < 0 {
-// 		panic("ringbuf.Buffer.Truncate: n < 0")
-// 	}
-// 	if n > r.Len() {
-// 		panic("ringbuf.Buffer.Truncate: n > r.Len()")
-// 	}
-// 	if n == r.Len() {
-// 		return
-// 	}
-// 	if r.writePos == 0 {
-// 		r.buf = r.buf[:r.readPos+n]
-// 		return
-// 	}
-// 	if end := r.writePos + r.readPos + n; end < len(r.buf) && /* overflow check: */ end > 0 {
-// 		copy(r.buf, r.buf[r.writePos:n])
-// 		r.buf = r.buf[:n-r.writePos]
-// 		return
-// 	}
-// }
+// Truncate discards all but the first n unread elements of the buffer
+// but continues to use the same allocated storage.
+//
+// It panics if n is negative or greater than b.Len().
+func (b *Buffer[T]) Truncate(n int) {
+	if n < 0 {
+		panic("ringbuf.Buffer.Truncate: n < 0")
+	}
+	if n > b.Len() {
+		panic("ringbuf.Buffer.Truncate: n > b.Len()")
+	}
+	b.linearize()
+	end := b.readPos + n
+	clear(b.buf[end:])
+	b.buf = b.buf[:end]
+}
+
+// DiscardPolicy specifies which data [Buffer.SetMaxLen] discards
+// when the buffer holds more than its new max length.
+type DiscardPolicy int
+
+const (
+	DiscardOldest DiscardPolicy = iota
+	DiscardNewest
+)
+
+// SetMaxLen changes the max length of the buffer.
+//
+// If the buffer holds more than n elements,
+// the excess is discarded according to policy,
+// and storage beyond n is released.
+//
+// It panics if n is negative.
+func (b *Buffer[T]) SetMaxLen(n int, policy DiscardPolicy) {
+
+	if n < 0 {
+		panic("ringbuf.Buffer.SetMaxLen: n < 0")
+	}
+
+	// The buffer only wraps around when it's full,
+	// which it's no longer once maxLen changes.
+	b.linearize()
+
+	if excess := len(b.buf) - n; excess > 0 {
+		switch policy {
+		case DiscardOldest:
+			b.overwrite(excess, nil)
+			copy(b.buf, b.buf[excess:])
+		case DiscardNewest:
+			b.drop(max(b.readPos, n), len(b.buf))
+			b.readPos = min(b.readPos, n)
+		default:
+			panic("ringbuf.Buffer.SetMaxLen: unknown policy")
+		}
+		clear(b.buf[n:])
+		b.buf = b.buf[:n]
+	}
+
+	if cap(b.buf) > n {
+		b.buf = append(make([]T, 0, n), b.buf...)
+	}
+
+	b.maxLen = n
+}
+
+// Seek sets the read cursor of the buffer, implementing [io.Seeker].
+//
+// Offsets are relative to the oldest element of the buffer
+// with [io.SeekStart], the read cursor with [io.SeekCurrent],
+// and the newest element with [io.SeekEnd].
+// Seeking past the end of the buffer moves the cursor to the end,
+// and seeking back before the oldest element is an error.
+func (b *Buffer[T]) Seek(offset int64, whence int) (int64, error) {
+
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(b.readPos) + offset
+	case io.SeekEnd:
+		pos = int64(len(b.buf)) + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if pos < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	b.readPos = int(min(pos, int64(len(b.buf))))
+	return int64(b.readPos), nil
+}
+
+// compact releases the storage of the elements before the read cursor,
+// moving the unread elements to an allocation of their size.
+func (b *Buffer[T]) compact() {
+	b.linearize()
+	b.buf = append(make([]T, 0, b.Len()), b.buf[b.readPos:]...)
+	b.readPos = 0
+}
+
+// linearize rotates the buffer in place so that writePos is 0.
+func (b *Buffer[T]) linearize() {
+	if b.writePos == 0 {
+		return
+	}
+	slices.Reverse(b.buf[:b.writePos])
+	slices.Reverse(b.buf[b.writePos:])
+	slices.Reverse(b.buf)
+	b.writePos = 0
+}
+
+// overwrite accounts for the n oldest elements of the buffer
+// being overwritten, moving the read cursor accordingly.
+// If n exceeds the length of the buffer,
+// the excess is taken from the start of src,
+// the data being written.
+func (b *Buffer[T]) overwrite(n int, src []T) {
+
+	if n <= 0 {
+		return
+	}
+
+	m := min(n, len(b.buf))
+	b.drop(b.readPos, m)
+
+	if k := n - m; k > 0 {
+		if b.onOverwrite != nil {
+			b.onOverwrite(src[:k])
+		}
+		b.countDropped(k)
+	}
+
+	b.readPos = max(b.readPos-n, 0)
+}
+
+// drop accounts for the unread elements between
+// the offsets from and to of the buffer being dropped.
+func (b *Buffer[T]) drop(from, to int) {
+
+	if from >= to {
+		return
+	}
+
+	if b.onOverwrite != nil {
+		n := to - from
+		for s := range b.seq(from) {
+			s = s[:min(len(s), n)]
+			b.onOverwrite(s)
+			n -= len(s)
+			if n == 0 {
+				break
+			}
+		}
+	}
+
+	b.countDropped(to - from)
+}
+
+func (b *Buffer[T]) countDropped(n int) {
+	b.dropped += uint64(n)
+	if b.reportOverruns {
+		b.overrun += uint64(n)
+	}
+}
+
+// Written returns the total number of elements written to the buffer.
+func (b *Buffer[T]) Written() uint64 {
+	return b.written
+}
+
+// Dropped returns the total number of elements
+// that were dropped from the buffer before being read,
+// because they were overwritten or discarded by [Buffer.SetMaxLen].
+func (b *Buffer[T]) Dropped() uint64 {
+	return b.dropped
+}
+
+// OnOverwrite sets a function to be called
+// with the unread elements about to be dropped from the buffer,
+// possibly in several calls per write.
+// The slices passed to fn alias the buffer content
+// and must not be retained.
+func (b *Buffer[T]) OnOverwrite(fn func(dropped []T)) {
+	b.onOverwrite = fn
+}
+
+// ReportOverruns sets whether [Buffer.Read] reports
+// unread elements being dropped with an [*OverrunError].
+//
+// Only Read and [Buffer.ReadByte] report overruns.
+// Other reading methods, such as [Buffer.Next], [Buffer.NextSeq],
+// [ByteBuffer.ReadRune] and [ByteBuffer.WriteTo], read regardless,
+// leaving the report to the next call to Read.
+func (b *Buffer[T]) ReportOverruns(report bool) {
+	b.reportOverruns = report
+	if !report {
+		b.overrun = 0
+	}
+}
+
+// ErrOverrun is wrapped by [*OverrunError].
+var ErrOverrun = errors.New("read cursor overrun")
+
+// OverrunError reports that unread elements were dropped.
+type OverrunError struct {
+	Dropped uint64
+}
+
+func (e *OverrunError) Error() string {
+	return fmt.Sprintf("read cursor overrun: %d elements dropped", e.Dropped)
+}
+
+func (e *OverrunError) Unwrap() error {
+	return ErrOverrun
+}
 
 func (b *Buffer[T]) Reset() {
 	b.buf = b.buf[:0]
@@ -4,7 +4,10 @@
 package ringbuf
 
 import (
+	"bufio"
+	"bytes"
 	"io"
+	"iter"
 	"unicode/utf8"
 	"unsafe"
 )
@@ -72,8 +75,9 @@ func (b *ByteBuffer) ReadFrom(r io.Reader) (n int64, err error) {
 
 	for b.rem() > 0 {
 		b.Grow(minRead)
-		m, err := r.Read(b.buf[len(b.buf):cap(b.buf)])
+		m, err := r.Read(b.buf[len(b.buf):min(cap(b.buf), b.maxLen)])
 		b.buf = b.buf[:len(b.buf)+m]
+		b.written += uint64(m)
 		n += int64(m)
 		if err == io.EOF {
 			return n, nil
@@ -83,13 +87,29 @@ func (b *ByteBuffer) ReadFrom(r io.Reader) (n int64, err error) {
 		}
 	}
 
+	// The overwrite hook must see the data before it's overwritten,
+	// so read it aside first if there's a hook.
+	var scratch []byte
+	if b.onOverwrite != nil {
+		scratch = make([]byte, min(b.maxLen, 32*1024))
+	}
+
 	for {
-		m, err := r.Read(b.buf[b.writePos:])
-		n += int64(m)
-		b.writePos += m
-		if b.writePos == b.maxLen {
-			b.writePos = 0
+		var m int
+		var err error
+		if scratch != nil {
+			m, err = r.Read(scratch)
+			b.Write(scratch[:m])
+		} else {
+			m, err = r.Read(b.buf[b.writePos:])
+			b.written += uint64(m)
+			b.overwrite(m, nil)
+			b.writePos += m
+			if b.writePos == b.maxLen {
+				b.writePos = 0
+			}
 		}
+		n += int64(m)
 		if err == io.EOF {
 			return n, nil
 		}
@@ -112,3 +132,211 @@ func (b *ByteBuffer) String() string {
 	b.ReadAt(s, int64(b.readPos))
 	return unsafe.String(&s[0], len(s))
 }
+
+// IndexByte returns the index of the first instance of c
+// in the unread data of the buffer, or -1 if c isn't present.
+func (b *ByteBuffer) IndexByte(c byte) int {
+	off := 0
+	for s := range b.BytesSeq() {
+		if i := bytes.IndexByte(s, c); i != -1 {
+			return off + i
+		}
+		off += len(s)
+	}
+	return -1
+}
+
+// Index returns the index of the first instance of sep
+// in the unread data of the buffer, or -1 if sep isn't present.
+func (b *ByteBuffer) Index(sep []byte) int {
+
+	if len(sep) == 0 {
+		return 0
+	}
+
+	off := 0
+	for s := range b.BytesSeq() {
+		if i := bytes.Index(s, sep); i != -1 {
+			return off + i
+		}
+		// Look for sep straddling the wrap boundary.
+		for i := max(len(s)-len(sep)+1, 0); i < len(s); i++ {
+			if s[i] == sep[0] && b.hasPrefixAt(off+i, sep) {
+				return off + i
+			}
+		}
+		off += len(s)
+	}
+
+	return -1
+}
+
+// HasPrefix reports whether the unread data of the buffer begins with prefix.
+func (b *ByteBuffer) HasPrefix(prefix []byte) bool {
+	return b.hasPrefixAt(0, prefix)
+}
+
+// hasPrefixAt reports whether the unread data of the buffer
+// has prefix at offset off.
+func (b *ByteBuffer) hasPrefixAt(off int, prefix []byte) bool {
+	for s := range b.seq(b.readPos + off) {
+		n := min(len(s), len(prefix))
+		if !bytes.Equal(s[:n], prefix[:n]) {
+			return false
+		}
+		prefix = prefix[n:]
+		if len(prefix) == 0 {
+			break
+		}
+	}
+	return len(prefix) == 0
+}
+
+// Peek returns the next n unread bytes of the buffer without advancing it,
+// or all of them and [io.EOF] if there are fewer than n.
+//
+// If the bytes wrap around the buffer, it's rotated in place first.
+// The returned slice aliases the buffer content
+// at least until the next buffer modification.
+func (b *ByteBuffer) Peek(n int) ([]byte, error) {
+	if n < 0 {
+		panic("ringbuf.ByteBuffer.Peek: n < 0")
+	}
+	s := b.contiguous(min(n, b.Len()))
+	if len(s) < n {
+		return s, io.EOF
+	}
+	return s, nil
+}
+
+// Discard skips the next n unread bytes of the buffer,
+// returning the number of bytes discarded,
+// and [io.EOF] if there were fewer than n.
+func (b *ByteBuffer) Discard(n int) (discarded int, err error) {
+	if n < 0 {
+		panic("ringbuf.ByteBuffer.Discard: n < 0")
+	}
+	discarded = min(n, b.Len())
+	b.readPos += discarded
+	if discarded < n {
+		return discarded, io.EOF
+	}
+	return discarded, nil
+}
+
+// ReadSlice reads until the first occurrence of delim in the unread data,
+// returning a slice of the data up to and including the delimiter.
+//
+// Unlike [bufio.Reader.ReadSlice], if delim isn't present,
+// it reads nothing and returns [io.EOF], as more data may be written later,
+// unless the buffer is full, in which case it reads all of it
+// and returns [bufio.ErrBufferFull].
+//
+// The returned slice aliases the buffer content
+// at least until the next buffer modification.
+func (b *ByteBuffer) ReadSlice(delim byte) (line []byte, err error) {
+
+	n := b.IndexByte(delim) + 1
+	if n == 0 {
+		if b.Len() == 0 || b.Len() < b.maxLen {
+			return nil, io.EOF
+		}
+		n = b.Len()
+		err = bufio.ErrBufferFull
+	}
+
+	line = b.contiguous(n)
+	b.readPos += n
+	return line, err
+}
+
+// ReadLine reads a line of the unread data
+// without its "\n" or "\r\n" terminator.
+// It's like [bufio.Reader.ReadLine],
+// with the differences of [ByteBuffer.ReadSlice].
+func (b *ByteBuffer) ReadLine() (line []byte, isPrefix bool, err error) {
+
+	line, err = b.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return line, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	line = line[:len(line)-1]
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	return line, false, nil
+}
+
+// Scan returns an iterator over the tokens of the unread data
+// found by split, a [bufio.Scanner] split function,
+// reading each token before yielding it.
+//
+// The iteration ends when split needs more data than the buffer has,
+// in which case it's called with atEOF false, as more may be written later.
+// It also ends after yielding an error returned by split,
+// other than [bufio.ErrFinalToken].
+//
+// The buffer is rotated in place first if its data wraps around.
+// The yielded tokens alias the buffer content
+// at least until the next buffer modification.
+func (b *ByteBuffer) Scan(split bufio.SplitFunc) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+
+		empty := 0
+
+		for {
+			data := b.contiguous(b.Len())
+			advance, token, err := split(data, false)
+			if err == bufio.ErrFinalToken {
+				b.readPos += advance
+				if token != nil {
+					yield(token, nil)
+				}
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if advance < 0 || advance > len(data) {
+				yield(nil, bufio.ErrBadReadCount)
+				return
+			}
+
+			b.readPos += advance
+
+			if token == nil {
+				if advance == 0 {
+					return
+				}
+				continue
+			}
+
+			if advance > 0 {
+				empty = 0
+			} else if empty++; empty > 100 {
+				panic("ringbuf.ByteBuffer.Scan: too many empty tokens without progressing")
+			}
+
+			if !yield(token, nil) {
+				return
+			}
+		}
+	}
+}
+
+// contiguous returns the next n unread bytes of the buffer,
+// which must have at least n of them,
+// rotating it in place if they wrap around it.
+func (b *ByteBuffer) contiguous(n int) []byte {
+	for s := range b.BytesSeq() {
+		if len(s) >= n {
+			return s[:n]
+		}
+		break
+	}
+	b.linearize()
+	return b.buf[b.readPos : b.readPos+n]
+}
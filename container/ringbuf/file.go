@@ -0,0 +1,472 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+)
+
+// File is a ring buffer stored in a file of fixed size,
+// for data that must survive restarts and crashes,
+// such as the diagnostics of a flight recorder.
+//
+// Each write is stored as a record with a checksum,
+// and old records are overwritten as a whole when the file is full.
+// The file starts with two copies of a header tracking
+// the records and the read cursor, written alternately,
+// so that a torn header write leaves the other intact.
+// When a file is opened, the records written after
+// the last header update are recovered up to the first torn one.
+//
+// Data written to a File survives crashes of the process;
+// use [File.Sync] to make it survive crashes of the system.
+type File struct {
+	f      *os.File
+	hdr    fileHeader
+	slot   int   // slot is the header slot to write next.
+	unread int   // unread is the number of unread bytes.
+	err    error // err is a sticky I/O error.
+	buf    [fileHeaderSize]byte
+}
+
+type fileHeader struct {
+	seq     uint64
+	size    uint64 // size is the size of the data region.
+	head    uint64 // head is the position of the oldest record.
+	tail    uint64 // tail is the position following the newest record.
+	readRec uint64 // readRec is the position of the record the read cursor is in.
+	readOff uint64 // readOff is the position of the read cursor in the record data.
+}
+
+// Positions in the data region grow monotonically,
+// and are taken modulo its size to get file offsets.
+
+const (
+	fileMagic       = "RBF1"
+	fileHeaderSize  = 64
+	fileDataStart   = 2 * fileHeaderSize
+	fileRecordHdr   = 8 // length and checksum
+	fileMinDataSize = 2 * fileRecordHdr
+)
+
+var (
+	ErrFileCorrupt = errors.New("corrupt ring buffer file")
+
+	fileCRCTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// OpenFile opens the ring buffer file with the given name,
+// creating it with a data region of size bytes if it doesn't exist.
+// A size of zero opens an existing file whatever its size.
+func OpenFile(name string, size int) (*File, error) {
+
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	rf := &File{f: f}
+	if err := rf.open(uint64(size)); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return rf, nil
+}
+
+func (f *File) open(size uint64) error {
+
+	st, err := f.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if st.Size() == 0 {
+		if size < fileMinDataSize {
+			return fmt.Errorf("size %d is too small", size)
+		}
+		if err := f.f.Truncate(int64(fileDataStart + size)); err != nil {
+			return err
+		}
+		f.hdr = fileHeader{size: size}
+		return f.writeHeader()
+	}
+
+	if err := f.readHeader(); err != nil {
+		return err
+	}
+	if size != 0 && size != f.hdr.size {
+		return fmt.Errorf("file has a size of %d, not %d", f.hdr.size, size)
+	}
+	if st.Size() < int64(fileDataStart+f.hdr.size) {
+		return fmt.Errorf("%w: file is truncated", ErrFileCorrupt)
+	}
+
+	if err := f.recover(); err != nil {
+		return err
+	}
+
+	pos := f.hdr.readRec
+	for pos < f.hdr.tail {
+		n, err := f.recordLen(pos)
+		if err != nil {
+			return err
+		}
+		f.unread += int(n)
+		pos += fileRecordHdr + uint64(n)
+	}
+	f.unread -= int(f.hdr.readOff)
+
+	return nil
+}
+
+// readHeader reads the valid header with the greatest sequence number.
+func (f *File) readHeader() error {
+
+	found := false
+
+	for slot := range 2 {
+
+		b := f.buf[:]
+		if _, err := f.f.ReadAt(b, int64(slot*fileHeaderSize)); err != nil {
+			return err
+		}
+
+		if string(b[:4]) != fileMagic ||
+			binary.LittleEndian.Uint32(b[56:]) != crc32.Checksum(b[:56], fileCRCTable) {
+			continue
+		}
+
+		h := fileHeader{
+			seq:     binary.LittleEndian.Uint64(b[8:]),
+			size:    binary.LittleEndian.Uint64(b[16:]),
+			head:    binary.LittleEndian.Uint64(b[24:]),
+			tail:    binary.LittleEndian.Uint64(b[32:]),
+			readRec: binary.LittleEndian.Uint64(b[40:]),
+			readOff: binary.LittleEndian.Uint64(b[48:]),
+		}
+
+		if !found || h.seq > f.hdr.seq {
+			f.hdr = h
+			f.slot = 1 - slot
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: no valid header", ErrFileCorrupt)
+	}
+
+	h := f.hdr
+	if h.size < fileMinDataSize || h.head > h.tail || h.tail-h.head > h.size ||
+		h.readRec < h.head || h.readRec > h.tail {
+		return fmt.Errorf("%w: invalid header", ErrFileCorrupt)
+	}
+
+	return nil
+}
+
+func (f *File) writeHeader() error {
+
+	f.hdr.seq++
+
+	b := f.buf[:]
+	clear(b)
+	copy(b, fileMagic)
+	binary.LittleEndian.PutUint64(b[8:], f.hdr.seq)
+	binary.LittleEndian.PutUint64(b[16:], f.hdr.size)
+	binary.LittleEndian.PutUint64(b[24:], f.hdr.head)
+	binary.LittleEndian.PutUint64(b[32:], f.hdr.tail)
+	binary.LittleEndian.PutUint64(b[40:], f.hdr.readRec)
+	binary.LittleEndian.PutUint64(b[48:], f.hdr.readOff)
+	binary.LittleEndian.PutUint32(b[56:], crc32.Checksum(b[:56], fileCRCTable))
+
+	_, err := f.f.WriteAt(b, int64(f.slot*fileHeaderSize))
+	f.slot = 1 - f.slot
+	return err
+}
+
+// recover adds the records written after the last header update,
+// stopping at the first incomplete or corrupt one.
+func (f *File) recover() error {
+
+	found := false
+
+	for {
+		var rh [fileRecordHdr]byte
+		pos := f.hdr.tail
+		if err := f.readData(rh[:], pos); err != nil {
+			return err
+		}
+
+		n := uint64(binary.LittleEndian.Uint32(rh[:]))
+		end := pos + fileRecordHdr + n
+		if n == 0 || end-f.hdr.head > f.hdr.size {
+			break
+		}
+
+		data := make([]byte, n)
+		if err := f.readData(data, pos+fileRecordHdr); err != nil {
+			return err
+		}
+		if binary.LittleEndian.Uint32(rh[4:]) != recordCRC(pos, data) {
+			break
+		}
+
+		f.hdr.tail = end
+		found = true
+	}
+
+	if found {
+		return f.writeHeader()
+	}
+	return nil
+}
+
+// recordCRC returns the checksum of a record,
+// which covers its position so that stale records
+// left over from previous laps don't pass for new ones.
+func recordCRC(pos uint64, data []byte) uint32 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], pos)
+	crc := crc32.Update(0, fileCRCTable, b[:])
+	return crc32.Update(crc, fileCRCTable, data)
+}
+
+// Write writes p to the file as a single record,
+// overwriting the oldest records as needed.
+// If p doesn't fit in the file, only its end is written.
+func (f *File) Write(p []byte) (int, error) {
+
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	n := len(p)
+	if n == 0 {
+		return 0, nil
+	}
+
+	maxData := int(min(f.hdr.size-fileRecordHdr, 1<<32-1))
+	if len(p) > maxData {
+		p = p[len(p)-maxData:]
+	}
+
+	pos := f.hdr.tail
+	end := pos + fileRecordHdr + uint64(len(p))
+
+	// Evict the records to be overwritten,
+	// and record it before overwriting them.
+	if end-f.hdr.head > f.hdr.size {
+		for end-f.hdr.head > f.hdr.size {
+			if err := f.evict(); err != nil {
+				return 0, f.fail(err)
+			}
+		}
+		if err := f.writeHeader(); err != nil {
+			return 0, f.fail(err)
+		}
+	}
+
+	var rh [fileRecordHdr]byte
+	binary.LittleEndian.PutUint32(rh[:], uint32(len(p)))
+	binary.LittleEndian.PutUint32(rh[4:], recordCRC(pos, p))
+
+	if err := f.writeData(rh[:], pos); err != nil {
+		return 0, f.fail(err)
+	}
+	if err := f.writeData(p, pos+fileRecordHdr); err != nil {
+		return 0, f.fail(err)
+	}
+
+	f.hdr.tail = end
+	f.unread += len(p)
+
+	if err := f.writeHeader(); err != nil {
+		return 0, f.fail(err)
+	}
+
+	return n, nil
+}
+
+// evict drops the oldest record, moving the read cursor past it if needed.
+func (f *File) evict() error {
+
+	n, err := f.recordLen(f.hdr.head)
+	if err != nil {
+		return err
+	}
+
+	next := f.hdr.head + fileRecordHdr + uint64(n)
+
+	switch {
+	case f.hdr.readRec == f.hdr.head:
+		f.unread -= int(uint64(n) - f.hdr.readOff)
+		f.hdr.readRec = next
+		f.hdr.readOff = 0
+	case f.hdr.readRec < f.hdr.head:
+		panic("ringbuf.File: read cursor before head")
+	}
+
+	f.hdr.head = next
+	return nil
+}
+
+// Read reads the unread data of the file,
+// returning [io.EOF] if there's none.
+// The read cursor is saved in the file.
+func (f *File) Read(p []byte) (n int, err error) {
+
+	if f.err != nil {
+		return 0, f.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(p) > 0 && f.hdr.readRec < f.hdr.tail {
+
+		size, err := f.recordLen(f.hdr.readRec)
+		if err != nil {
+			return n, f.fail(err)
+		}
+
+		m := min(uint64(len(p)), uint64(size)-f.hdr.readOff)
+		if err := f.readData(p[:m], f.hdr.readRec+fileRecordHdr+f.hdr.readOff); err != nil {
+			return n, f.fail(err)
+		}
+
+		p = p[m:]
+		n += int(m)
+		f.unread -= int(m)
+		f.hdr.readOff += m
+
+		if f.hdr.readOff == uint64(size) {
+			f.hdr.readRec += fileRecordHdr + uint64(size)
+			f.hdr.readOff = 0
+		}
+	}
+
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	if err := f.writeHeader(); err != nil {
+		return n, f.fail(err)
+	}
+
+	return n, nil
+}
+
+// BytesSeq returns an iterator over the unread data of the file,
+// a record at a time, without advancing the read cursor.
+//
+// The iteration stops at the first I/O error,
+// which is returned by subsequent calls to the other methods.
+func (f *File) BytesSeq() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+
+		if f.err != nil {
+			return
+		}
+
+		pos, off := f.hdr.readRec, f.hdr.readOff
+
+		for pos < f.hdr.tail {
+
+			size, err := f.recordLen(pos)
+			if err != nil {
+				f.fail(err)
+				return
+			}
+
+			b := make([]byte, uint64(size)-off)
+			if err := f.readData(b, pos+fileRecordHdr+off); err != nil {
+				f.fail(err)
+				return
+			}
+
+			if !yield(b) {
+				return
+			}
+
+			pos += fileRecordHdr + uint64(size)
+			off = 0
+		}
+	}
+}
+
+// Len returns the number of unread bytes of the file.
+func (f *File) Len() int {
+	return f.unread
+}
+
+// Size returns the size of the data region of the file,
+// which holds the records and their 8-byte headers.
+func (f *File) Size() int {
+	return int(f.hdr.size)
+}
+
+// Sync commits the file to stable storage.
+func (f *File) Sync() error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.fail(f.f.Sync())
+}
+
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+func (f *File) fail(err error) error {
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return err
+}
+
+func (f *File) recordLen(pos uint64) (uint32, error) {
+	var b [4]byte
+	if err := f.readData(b[:], pos); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// readData reads len(p) bytes of the data region at pos, wrapping around.
+func (f *File) readData(p []byte, pos uint64) error {
+	off := pos % f.hdr.size
+	m := min(uint64(len(p)), f.hdr.size-off)
+	if _, err := f.f.ReadAt(p[:m], int64(fileDataStart+off)); err != nil {
+		return err
+	}
+	if m < uint64(len(p)) {
+		if _, err := f.f.ReadAt(p[m:], fileDataStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeData writes p to the data region at pos, wrapping around.
+func (f *File) writeData(p []byte, pos uint64) error {
+	off := pos % f.hdr.size
+	m := min(uint64(len(p)), f.hdr.size-off)
+	if _, err := f.f.WriteAt(p[:m], int64(fileDataStart+off)); err != nil {
+		return err
+	}
+	if m < uint64(len(p)) {
+		if _, err := f.f.WriteAt(p[m:], fileDataStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestFile(t *testing.T) {
+
+	name := filepath.Join(t.TempDir(), "ring")
+
+	f, err := ringbuf.OpenFile(name, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records take 8 bytes more than their data,
+	// so the file holds the last three 5-byte records.
+	for _, s := range []string{"aaaaa", "bbbbb", "ccccc", "ddddd"} {
+		f.Write([]byte(s))
+	}
+	assertEqual(t, "f.Len", 15, f.Len())
+
+	buf := make([]byte, 7)
+	n, _ := f.Read(buf)
+	diff(t, "f.Read", "bbbbbcc", buf[:n])
+
+	// The oldest record is overwritten as a whole,
+	// including the unread part of the record being read.
+	f.Write([]byte("eeeee"))
+	f.Write([]byte("fffff"))
+	if diff := cmp.Diff([]string{"ddddd", "eeeee", "fffff"}, bytesStrings(f.BytesSeq())); diff != "" {
+		t.Errorf("f.BytesSeq: incorrect result (-want +got):\n%s", diff)
+	}
+
+	n, _ = f.Read(buf[:2])
+	diff(t, "f.Read", "dd", buf[:n])
+	f.Close()
+
+	// The data and the read cursor survive reopening.
+	f, err = ringbuf.OpenFile(name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	assertEqual(t, "f.Len", 13, f.Len())
+	got, _ := io.ReadAll(f)
+	diff(t, "io.ReadAll", "dddeeeeefffff", got)
+
+	// Data longer than the file keeps its end.
+	f.Write([]byte("0123456789012345678901234567890123456789"))
+	got, _ = io.ReadAll(f)
+	diff(t, "io.ReadAll", "89012345678901234567890123456789", got)
+
+	if _, err := ringbuf.OpenFile(name, 64); err == nil {
+		t.Fatal("ringbuf.OpenFile with another size succeeded")
+	}
+}
+
+func TestFileRecover(t *testing.T) {
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "ring")
+
+	f, err := ringbuf.OpenFile(name, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("first"))
+	before := readFile(t, name)
+	f.Write([]byte("second"))
+	f.Close()
+	after := readFile(t, name)
+
+	// Simulate a crash between writing the second record
+	// and updating the header.
+	const headers = 128
+	crashed := slices.Concat(before[:headers], after[headers:])
+
+	writeFile(t, name, crashed)
+	assertFileRecords(t, name, []string{"first", "second"})
+
+	// A torn record is dropped.
+	crashed[len("first")+8+8+headers+2] ^= 0xff
+	writeFile(t, name, crashed)
+	assertFileRecords(t, name, []string{"first"})
+
+	// A torn header falls back to the other one.
+	torn := slices.Clone(after)
+	for i := range 2 {
+		writeFile(t, name, torn)
+		assertFileRecords(t, name, []string{"first", "second"})
+		torn[i*64+20] ^= 0xff
+	}
+
+	writeFile(t, name, torn)
+	if _, err := ringbuf.OpenFile(name, 0); err == nil {
+		t.Fatal("ringbuf.OpenFile with torn headers succeeded")
+	}
+}
+
+func assertFileRecords(t *testing.T, name string, want []string) {
+	t.Helper()
+	f, err := ringbuf.OpenFile(name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if diff := cmp.Diff(want, bytesStrings(f.BytesSeq())); diff != "" {
+		t.Errorf("f.BytesSeq: incorrect result (-want +got):\n%s", diff)
+	}
+}
+
+func bytesStrings(seq iter.Seq[[]byte]) []string {
+	s := []string{}
+	for b := range seq {
+		s = append(s, string(b))
+	}
+	return s
+}
+
+func readFile(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func writeFile(t *testing.T, name string, b []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
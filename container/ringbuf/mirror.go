@@ -0,0 +1,171 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// MirrorBuffer is a byte ring buffer whose unread data
+// is always available as a single contiguous slice,
+// for handing it to parsers without copying it.
+//
+// On Linux, its storage is a memory file mapped twice in a row,
+// so that data wrapping around the end of the first mapping
+// continues in the second one.
+// Elsewhere, it falls back to a [ByteBuffer],
+// which is rotated in place when a contiguous slice is needed.
+//
+// A MirrorBuffer must be closed to release its memory.
+type MirrorBuffer struct {
+	mem    []byte // mem is the data region mapped twice.
+	size   int    // size is the size of the data region.
+	start  int    // start is the position of the unread data in the data region.
+	n      int    // n is the number of unread bytes.
+	maxLen int
+	fb     *ByteBuffer // fb holds the data if mirroring isn't supported.
+}
+
+// NewMirrorBuffer returns a new mirrored ring buffer
+// holding up to maxLen bytes, then overwriting old data.
+//
+// It panics if maxLen isn't positive.
+func NewMirrorBuffer(maxLen int) (*MirrorBuffer, error) {
+
+	if maxLen <= 0 {
+		panic("ringbuf.NewMirrorBuffer: maxLen <= 0")
+	}
+
+	mem, err := mapMirror(maxLen)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return &MirrorBuffer{maxLen: maxLen, fb: NewByteBuffer(maxLen)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &MirrorBuffer{mem: mem, size: len(mem) / 2, maxLen: maxLen}, nil
+}
+
+func (b *MirrorBuffer) Write(p []byte) (int, error) {
+
+	if b.fb != nil {
+		return b.fb.Write(p)
+	}
+
+	n := len(p)
+	if len(p) > b.maxLen {
+		p = p[len(p)-b.maxLen:]
+	}
+
+	// The data region is mirrored, so copying
+	// past its end writes to its start.
+	w := (b.start + b.n) % b.size
+	copy(b.mem[w:], p)
+
+	b.n += len(p)
+	if d := b.n - b.maxLen; d > 0 {
+		b.start = (b.start + d) % b.size
+		b.n = b.maxLen
+	}
+
+	return n, nil
+}
+
+func (b *MirrorBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+func (b *MirrorBuffer) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	s := b.Bytes()
+	if len(s) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, s)
+	b.advance(n)
+	return n, nil
+}
+
+// Bytes returns the unread data of the buffer.
+//
+// The returned slice aliases the buffer content
+// at least until the next buffer modification.
+func (b *MirrorBuffer) Bytes() []byte {
+	if b.fb != nil {
+		b.fb.linearize()
+		return b.fb.buf[b.fb.readPos:]
+	}
+	return b.mem[b.start : b.start+b.n]
+}
+
+// BytesSeq returns an iterator yielding the unread data of the buffer
+// as a single slice, like [MirrorBuffer.Bytes],
+// for compatibility with [Buffer.BytesSeq].
+func (b *MirrorBuffer) BytesSeq() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		if s := b.Bytes(); len(s) > 0 {
+			yield(s)
+		}
+	}
+}
+
+// Next returns the first n unread bytes of the buffer,
+// advancing the buffer as if they had been returned by [MirrorBuffer.Read].
+//
+// The returned slice aliases the buffer content
+// at least until the next buffer modification.
+func (b *MirrorBuffer) Next(n int) []byte {
+	if n < 0 {
+		panic("ringbuf.MirrorBuffer.Next: n < 0")
+	}
+	s := b.Bytes()
+	s = s[:min(n, len(s))]
+	b.advance(len(s))
+	return s
+}
+
+func (b *MirrorBuffer) advance(n int) {
+	if b.fb != nil {
+		b.fb.readPos += n
+		return
+	}
+	b.start = (b.start + n) % b.size
+	b.n -= n
+}
+
+func (b *MirrorBuffer) Len() int {
+	if b.fb != nil {
+		return b.fb.Len()
+	}
+	return b.n
+}
+
+func (b *MirrorBuffer) MaxLen() int {
+	return b.maxLen
+}
+
+func (b *MirrorBuffer) Reset() {
+	if b.fb != nil {
+		b.fb.Reset()
+		return
+	}
+	b.start = 0
+	b.n = 0
+}
+
+// Close releases the memory of the buffer,
+// which must not be used afterwards.
+func (b *MirrorBuffer) Close() error {
+	if b.mem == nil {
+		return nil
+	}
+	err := unmapMirror(b.mem)
+	b.mem = nil
+	return err
+}
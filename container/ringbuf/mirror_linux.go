@@ -0,0 +1,93 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package ringbuf
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// sysMemfdCreate is the number of the memfd_create system call,
+// which the syscall package doesn't define.
+// It's only set on 64-bit architectures,
+// whose mmap system call takes its arguments directly.
+var sysMemfdCreate = map[string]uintptr{
+	"amd64":    319,
+	"arm64":    279,
+	"loong64":  279,
+	"mips64":   5314,
+	"mips64le": 5314,
+	"ppc64":    360,
+	"ppc64le":  360,
+	"riscv64":  279,
+}[runtime.GOARCH]
+
+var memfdName = []byte("ringbuf\x00")
+
+// mapMirror maps a memory file of at least maxLen bytes,
+// rounded up to the page size, twice in a row.
+func mapMirror(maxLen int) ([]byte, error) {
+
+	if sysMemfdCreate == 0 {
+		return nil, syscall.ENOSYS
+	}
+
+	page := os.Getpagesize()
+	size := (maxLen + page - 1) / page * page
+
+	const MFD_CLOEXEC = 0x1
+
+	fd, _, errno := syscall.Syscall(
+		//
+		sysMemfdCreate,
+		//
+		uintptr(unsafe.Pointer(&memfdName[0])),
+		MFD_CLOEXEC,
+		0,
+	)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	// The mappings keep the memory file alive.
+	defer syscall.Close(int(fd))
+
+	if err := syscall.Ftruncate(int(fd), int64(size)); err != nil {
+		return nil, err
+	}
+
+	// Reserve the address range, then map the file over both halves.
+	mem, err := syscall.Mmap(-1, 0, 2*size, syscall.PROT_NONE, syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range 2 {
+		_, _, errno := syscall.Syscall6(
+			//
+			syscall.SYS_MMAP,
+			//
+			uintptr(unsafe.Pointer(&mem[i*size])),
+			uintptr(size),
+			syscall.PROT_READ|syscall.PROT_WRITE,
+			syscall.MAP_SHARED|syscall.MAP_FIXED,
+			fd,
+			0,
+		)
+		if errno != 0 {
+			syscall.Munmap(mem)
+			return nil, errno
+		}
+	}
+
+	return mem, nil
+}
+
+func unmapMirror(mem []byte) error {
+	return syscall.Munmap(mem)
+}
@@ -0,0 +1,16 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package ringbuf
+
+import "errors"
+
+func mapMirror(int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func unmapMirror([]byte) error {
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestMirrorBuffer(t *testing.T) {
+
+	const maxLen = 5000
+
+	b, err := ringbuf.NewMirrorBuffer(maxLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// The byte buffer is the model of the mirror buffer.
+	model := ringbuf.NewByteBuffer(maxLen)
+	rng := rand.New(rand.NewPCG(1, 2))
+	buf := make([]byte, 2*maxLen)
+
+	for i := range 1000 {
+
+		p := buf[:rng.IntN(len(buf))]
+		for j := range p {
+			p[j] = byte(rng.Uint32())
+		}
+		b.Write(p)
+		model.Write(p)
+
+		n := rng.IntN(maxLen)
+		got := b.Next(n)
+		want := model.Next(n)
+		if !bytes.Equal(want, got) {
+			t.Fatalf("iteration %d: b.Next(%d) doesn't match the model", i, n)
+		}
+
+		if !bytes.Equal(model.Bytes(), b.Bytes()) {
+			t.Fatalf("iteration %d: b.Bytes doesn't match the model", i)
+		}
+		assertEqual(t, "b.Len", model.Len(), b.Len())
+	}
+
+	b.Reset()
+	b.WriteString("hello")
+	got, _ := io.ReadAll(b)
+	diff(t, "io.ReadAll", "hello", got)
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// FullPolicy specifies what writes to a full [Pipe] do.
+type FullPolicy int
+
+const (
+	// Block blocks the writer until the reader makes room.
+	Block FullPolicy = iota
+
+	// Overwrite overwrites the oldest unread data.
+	Overwrite
+
+	// Fail writes what fits and fails with [ErrFull].
+	Fail
+)
+
+var ErrFull = errors.New("buffer full")
+
+// Pipe is a goroutine-safe ring buffer
+// connecting writers and readers on different goroutines,
+// similar to [io.Pipe] but buffered up to a max length.
+type Pipe[T any] struct {
+	mu      sync.Mutex
+	buf     *Buffer[T]
+	policy  FullPolicy
+	err     error // err is the error returned by reads once the pipe is closed and drained.
+	closed  bool
+	changed chan struct{} // changed is closed and replaced when the pipe state changes.
+}
+
+// NewPipe returns a new pipe buffering up to maxLen elements,
+// handling writes to the full pipe according to policy.
+//
+// It panics if maxLen isn't positive.
+func NewPipe[T any](maxLen int, policy FullPolicy) *Pipe[T] {
+	if maxLen <= 0 {
+		panic("ringbuf.NewPipe: maxLen <= 0")
+	}
+	return &Pipe[T]{
+		buf:     NewBuffer[T](maxLen),
+		policy:  policy,
+		changed: make(chan struct{}),
+	}
+}
+
+// Read reads unread data from the pipe,
+// blocking until there's some or the pipe is closed.
+// Once the pipe is closed and drained, Read returns
+// the error passed to [Pipe.CloseWithError], or [io.EOF].
+func (p *Pipe[T]) Read(dest []T) (int, error) {
+	return p.ReadContext(context.Background(), dest)
+}
+
+// ReadContext is like [Pipe.Read],
+// but stops waiting for data when ctx is done.
+func (p *Pipe[T]) ReadContext(ctx context.Context, dest []T) (int, error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(dest) == 0 {
+		return 0, nil
+	}
+
+	for p.buf.Len() == 0 {
+		if p.closed {
+			return 0, p.err
+		}
+		if err := p.wait(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	n, _ := p.buf.Read(dest)
+	p.notify()
+	return n, nil
+}
+
+// Write writes src to the pipe,
+// handling a full pipe according to its [FullPolicy].
+// Writing to a closed pipe fails with [io.ErrClosedPipe].
+func (p *Pipe[T]) Write(src []T) (int, error) {
+	return p.WriteContext(context.Background(), src)
+}
+
+// WriteContext is like [Pipe.Write],
+// but stops waiting for room when ctx is done.
+func (p *Pipe[T]) WriteContext(ctx context.Context, src []T) (n int, err error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(src) > 0 {
+
+		if p.closed {
+			return n, io.ErrClosedPipe
+		}
+
+		m := len(src)
+		if p.policy != Overwrite {
+			m = min(m, p.buf.MaxLen()-p.buf.Len())
+		}
+
+		if m > 0 {
+			p.buf.Write(src[:m])
+			p.notify()
+			src = src[m:]
+			n += m
+			continue
+		}
+
+		if p.policy == Fail {
+			return n, ErrFull
+		}
+
+		if err := p.wait(ctx); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Close closes the pipe; see [Pipe.CloseWithError].
+func (p *Pipe[T]) Close() error {
+	return p.CloseWithError(nil)
+}
+
+// CloseWithError closes the pipe.
+// Subsequent writes fail with [io.ErrClosedPipe],
+// and reads return the buffered data and then err,
+// or [io.EOF] if err is nil.
+//
+// Closing an already closed pipe has no effect.
+func (p *Pipe[T]) CloseWithError(err error) error {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+
+	if err == nil {
+		err = io.EOF
+	}
+
+	p.closed = true
+	p.err = err
+	p.notify()
+
+	return nil
+}
+
+// Len returns the number of unread elements in the pipe.
+func (p *Pipe[T]) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buf.Len()
+}
+
+// wait unlocks p.mu until the state of the pipe changes or ctx is done.
+func (p *Pipe[T]) wait(ctx context.Context) error {
+	changed := p.changed
+	p.mu.Unlock()
+	defer p.mu.Lock()
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (p *Pipe[T]) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestPipeBlock(t *testing.T) {
+
+	p := ringbuf.NewPipe[byte](7, ringbuf.Block)
+	want := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+
+	go func() {
+		src := want
+		for len(src) > 0 {
+			n := min(len(src), 13)
+			if _, err := p.Write(src[:n]); err != nil {
+				panic(err)
+			}
+			src = src[n:]
+		}
+		p.Close()
+	}()
+
+	got, err := io.ReadAll(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff(t, "io.ReadAll", want, got)
+}
+
+func TestPipeOverwrite(t *testing.T) {
+
+	p := ringbuf.NewPipe[byte](4, ringbuf.Overwrite)
+	p.Write([]byte("abc"))
+	p.Write([]byte("def"))
+	p.Close()
+
+	got, err := io.ReadAll(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff(t, "io.ReadAll", "cdef", got)
+}
+
+func TestPipeFail(t *testing.T) {
+
+	p := ringbuf.NewPipe[byte](4, ringbuf.Fail)
+
+	n, err := p.Write([]byte("abcdef"))
+	if n != 4 || !errors.Is(err, ringbuf.ErrFull) {
+		t.Fatalf("Write = %d, %v; want 4, ErrFull", n, err)
+	}
+
+	p.Read(make([]byte, 1))
+	if n, err := p.Write([]byte("e")); n != 1 || err != nil {
+		t.Fatalf("Write = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestPipeClose(t *testing.T) {
+
+	p := ringbuf.NewPipe[byte](4, ringbuf.Block)
+	errBoom := errors.New("boom")
+
+	p.Write([]byte("ab"))
+	p.CloseWithError(errBoom)
+
+	if _, err := p.Write([]byte("c")); err != io.ErrClosedPipe {
+		t.Fatalf("Write after close: got %v, want io.ErrClosedPipe", err)
+	}
+
+	got, err := io.ReadAll(p)
+	if err != errBoom {
+		t.Fatalf("ReadAll: got %v, want %v", err, errBoom)
+	}
+	diff(t, "io.ReadAll", "ab", got)
+}
+
+func TestPipeContext(t *testing.T) {
+
+	p := ringbuf.NewPipe[byte](2, ringbuf.Block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.ReadContext(ctx, make([]byte, 1)); err != context.DeadlineExceeded {
+		t.Fatalf("ReadContext on empty pipe: got %v, want context.DeadlineExceeded", err)
+	}
+
+	n, err := p.WriteContext(ctx, []byte("abc"))
+	if n != 2 || err != context.DeadlineExceeded {
+		t.Fatalf("WriteContext on full pipe = %d, %v; want 2, context.DeadlineExceeded", n, err)
+	}
+}
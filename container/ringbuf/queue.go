@@ -0,0 +1,323 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"context"
+	"math/bits"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// cacheLinePad separates fields written by different goroutines
+// to avoid false sharing.
+type cacheLinePad [64]byte
+
+// SPSC is a fixed-capacity lock-free queue
+// for a single producer goroutine and a single consumer goroutine.
+//
+// Pushing methods must only be called by the producer,
+// and popping methods only by the consumer.
+type SPSC[T any] struct {
+	_    cacheLinePad
+	head atomic.Uint64 // head is the position of the next pop, written by the consumer.
+	_    cacheLinePad
+	tail atomic.Uint64 // tail is the position of the next push, written by the producer.
+	_    cacheLinePad
+	buf  []T
+	mask uint64
+}
+
+// NewSPSC returns a new queue holding up to capacity elements,
+// rounded up to a power of two.
+//
+// It panics if capacity isn't positive.
+func NewSPSC[T any](capacity int) *SPSC[T] {
+	n := queueCap("NewSPSC", capacity)
+	return &SPSC[T]{
+		buf:  make([]T, n),
+		mask: uint64(n - 1),
+	}
+}
+
+// TryPush adds v to the queue and reports whether it did,
+// i.e. whether the queue wasn't full.
+func (q *SPSC[T]) TryPush(v T) bool {
+	tail := q.tail.Load()
+	if tail-q.head.Load() == uint64(len(q.buf)) {
+		return false
+	}
+	q.buf[tail&q.mask] = v
+	q.tail.Store(tail + 1)
+	return true
+}
+
+// TryPushBatch adds as many elements of src as fit to the queue
+// and returns their number.
+func (q *SPSC[T]) TryPushBatch(src []T) int {
+	tail := q.tail.Load()
+	n := min(len(src), len(q.buf)-int(tail-q.head.Load()))
+	i := int(tail & q.mask)
+	m := copy(q.buf[i:], src[:n])
+	copy(q.buf, src[m:n])
+	q.tail.Store(tail + uint64(n))
+	return n
+}
+
+// TryPop removes the oldest element from the queue and returns it,
+// reporting whether there was one.
+func (q *SPSC[T]) TryPop() (v T, ok bool) {
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return v, false
+	}
+	i := head & q.mask
+	v = q.buf[i]
+	clear(q.buf[i : i+1])
+	q.head.Store(head + 1)
+	return v, true
+}
+
+// TryPopBatch removes up to len(dest) of the oldest elements
+// from the queue into dest and returns their number.
+func (q *SPSC[T]) TryPopBatch(dest []T) int {
+	head := q.head.Load()
+	n := min(len(dest), int(q.tail.Load()-head))
+	i := int(head & q.mask)
+	m := copy(dest[:n], q.buf[i:])
+	clear(q.buf[i : i+m])
+	copy(dest[m:n], q.buf)
+	clear(q.buf[:n-m])
+	q.head.Store(head + uint64(n))
+	return n
+}
+
+// Push adds v to the queue, waiting for room until ctx is done.
+func (q *SPSC[T]) Push(ctx context.Context, v T) error {
+	var b backoff
+	for !q.TryPush(v) {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pop removes the oldest element from the queue and returns it,
+// waiting for one until ctx is done.
+func (q *SPSC[T]) Pop(ctx context.Context) (T, error) {
+	var b backoff
+	for {
+		if v, ok := q.TryPop(); ok {
+			return v, nil
+		}
+		if err := b.wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// Len returns the number of elements in the queue.
+func (q *SPSC[T]) Len() int {
+	head := q.head.Load()
+	return int(q.tail.Load() - head)
+}
+
+func (q *SPSC[T]) Cap() int {
+	return len(q.buf)
+}
+
+// MPMC is a fixed-capacity lock-free queue
+// for any number of producer and consumer goroutines.
+//
+// It's an implementation of Dmitry Vyukov's bounded MPMC queue,
+// in which each cell has a sequence number telling
+// whether it's ready to be pushed to or popped from.
+type MPMC[T any] struct {
+	_     cacheLinePad
+	head  atomic.Uint64
+	_     cacheLinePad
+	tail  atomic.Uint64
+	_     cacheLinePad
+	cells []mpmcCell[T]
+	mask  uint64
+}
+
+type mpmcCell[T any] struct {
+	seq atomic.Uint64
+	val T
+}
+
+// NewMPMC returns a new queue holding up to capacity elements,
+// rounded up to a power of two, and at least 2.
+//
+// It panics if capacity isn't positive.
+func NewMPMC[T any](capacity int) *MPMC[T] {
+	n := max(queueCap("NewMPMC", capacity), 2)
+	q := &MPMC[T]{
+		cells: make([]mpmcCell[T], n),
+		mask:  uint64(n - 1),
+	}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// TryPush adds v to the queue and reports whether it did,
+// i.e. whether the queue wasn't full.
+func (q *MPMC[T]) TryPush(v T) bool {
+	pos := q.tail.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		switch d := int64(c.seq.Load() - pos); {
+		case d == 0:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				c.val = v
+				c.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.tail.Load()
+		case d < 0:
+			return false
+		default:
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// TryPushBatch adds as many elements of src as fit to the queue
+// and returns their number.
+// Elements pushed concurrently by other producers
+// may be interleaved with those of src.
+func (q *MPMC[T]) TryPushBatch(src []T) int {
+	for i, v := range src {
+		if !q.TryPush(v) {
+			return i
+		}
+	}
+	return len(src)
+}
+
+// TryPop removes the oldest element from the queue and returns it,
+// reporting whether there was one.
+func (q *MPMC[T]) TryPop() (v T, ok bool) {
+	pos := q.head.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		switch d := int64(c.seq.Load() - (pos + 1)); {
+		case d == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				v = c.val
+				var zero T
+				c.val = zero
+				c.seq.Store(pos + q.mask + 1)
+				return v, true
+			}
+			pos = q.head.Load()
+		case d < 0:
+			return v, false
+		default:
+			pos = q.head.Load()
+		}
+	}
+}
+
+// TryPopBatch removes up to len(dest) of the oldest elements
+// from the queue into dest and returns their number.
+func (q *MPMC[T]) TryPopBatch(dest []T) int {
+	for i := range dest {
+		v, ok := q.TryPop()
+		if !ok {
+			return i
+		}
+		dest[i] = v
+	}
+	return len(dest)
+}
+
+// Push adds v to the queue, waiting for room until ctx is done.
+func (q *MPMC[T]) Push(ctx context.Context, v T) error {
+	var b backoff
+	for !q.TryPush(v) {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pop removes the oldest element from the queue and returns it,
+// waiting for one until ctx is done.
+func (q *MPMC[T]) Pop(ctx context.Context) (T, error) {
+	var b backoff
+	for {
+		if v, ok := q.TryPop(); ok {
+			return v, nil
+		}
+		if err := b.wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// Len returns the number of elements in the queue.
+// It's only a snapshot when the queue is used concurrently.
+func (q *MPMC[T]) Len() int {
+	head := q.head.Load()
+	tail := q.tail.Load()
+	return int(min(max(int64(tail-head), 0), int64(len(q.cells))))
+}
+
+func (q *MPMC[T]) Cap() int {
+	return len(q.cells)
+}
+
+func queueCap(fn string, capacity int) int {
+	if capacity <= 0 {
+		panic("ringbuf." + fn + ": capacity <= 0")
+	}
+	return 1 << bits.Len(uint(capacity-1))
+}
+
+// backoff waits between attempts of the blocking queue operations,
+// first yielding the processor, then sleeping increasingly long.
+type backoff struct {
+	n     int
+	timer *time.Timer
+}
+
+const (
+	backoffYields   = 16
+	backoffMaxSleep = time.Millisecond
+)
+
+func (b *backoff) wait(ctx context.Context) error {
+
+	if b.n < backoffYields {
+		b.n++
+		runtime.Gosched()
+		return context.Cause(ctx)
+	}
+
+	d := min(time.Microsecond<<min(b.n-backoffYields, 10), backoffMaxSleep)
+	b.n++
+
+	if b.timer == nil {
+		b.timer = time.NewTimer(d)
+	} else {
+		b.timer.Reset(d)
+	}
+
+	select {
+	case <-ctx.Done():
+		b.timer.Stop()
+		return context.Cause(ctx)
+	case <-b.timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestSPSC(t *testing.T) {
+
+	q := ringbuf.NewSPSC[int](5)
+	assertEqual(t, "q.Cap", 8, q.Cap())
+
+	assertEqual(t, "q.TryPushBatch", 6, q.TryPushBatch([]int{0, 1, 2, 3, 4, 5}))
+	dest := make([]int, 4)
+	assertEqual(t, "q.TryPopBatch", 4, q.TryPopBatch(dest))
+	assertEqual(t, "q.TryPushBatch", 6, q.TryPushBatch([]int{6, 7, 8, 9, 10, 11, 12}))
+	assertEqual(t, "q.TryPush", false, q.TryPush(13))
+	assertEqual(t, "q.Len", 8, q.Len())
+
+	dest = make([]int, 10)
+	n := q.TryPopBatch(dest)
+	if want := []int{4, 5, 6, 7, 8, 9, 10, 11}; !slices.Equal(dest[:n], want) {
+		t.Fatalf("q.TryPopBatch: want %v, got %v", want, dest[:n])
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Fatal("q.TryPop on empty queue succeeded")
+	}
+
+	const count = 100000
+
+	go func() {
+		for i := range count {
+			q.Push(context.Background(), i)
+		}
+	}()
+
+	for i := range count {
+		v, err := q.Pop(context.Background())
+		if err != nil || v != i {
+			t.Fatalf("q.Pop = %d, %v; want %d, nil", v, err, i)
+		}
+	}
+}
+
+func TestMPMC(t *testing.T) {
+
+	const (
+		producers = 4
+		consumers = 4
+		count     = 20000
+	)
+
+	q := ringbuf.NewMPMC[int](64)
+
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range count {
+				q.Push(context.Background(), p*count+i)
+			}
+		}()
+	}
+
+	got := make([][]int, consumers)
+	var cwg sync.WaitGroup
+	for c := range consumers {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			for range producers * count / consumers {
+				v, _ := q.Pop(context.Background())
+				got[c] = append(got[c], v)
+			}
+		}()
+	}
+
+	wg.Wait()
+	cwg.Wait()
+
+	// Each consumer sees the elements of each producer in order.
+	all := []int{}
+	for _, vals := range got {
+		last := make([]int, producers)
+		for i := range last {
+			last[i] = -1
+		}
+		for _, v := range vals {
+			p := v / count
+			if v <= last[p] {
+				t.Fatalf("producer %d: %d popped after %d", p, v, last[p])
+			}
+			last[p] = v
+		}
+		all = append(all, vals...)
+	}
+
+	slices.Sort(all)
+	for i, v := range all {
+		if v != i {
+			t.Fatalf("missing or duplicate element %d", i)
+		}
+	}
+}
+
+func TestQueueContext(t *testing.T) {
+
+	q := ringbuf.NewMPMC[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := q.Pop(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("q.Pop on empty queue: got %v, want context.DeadlineExceeded", err)
+	}
+
+	for q.TryPush(1) {
+	}
+	if err := q.Push(ctx, 2); err != context.DeadlineExceeded {
+		t.Fatalf("q.Push on full queue: got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+const benchQueueCap = 1024
+
+func BenchmarkSPSC(b *testing.B) {
+	q := ringbuf.NewSPSC[int](benchQueueCap)
+	benchPair(b, func(v int) {
+		for !q.TryPush(v) {
+			runtime.Gosched()
+		}
+	}, func() {
+		for {
+			if _, ok := q.TryPop(); ok {
+				return
+			}
+			runtime.Gosched()
+		}
+	})
+}
+
+func BenchmarkSPSCBatch(b *testing.B) {
+	q := ringbuf.NewSPSC[int](benchQueueCap)
+	batch := make([]int, 64)
+	benchPair(b, func(int) {
+		for src := batch; len(src) > 0; {
+			src = src[q.TryPushBatch(src):]
+			runtime.Gosched()
+		}
+	}, func() {
+		for dest := batch; len(dest) > 0; {
+			dest = dest[q.TryPopBatch(dest):]
+			runtime.Gosched()
+		}
+	})
+}
+
+func BenchmarkMPMC(b *testing.B) {
+	q := ringbuf.NewMPMC[int](benchQueueCap)
+	benchPair(b, func(v int) {
+		for !q.TryPush(v) {
+			runtime.Gosched()
+		}
+	}, func() {
+		for {
+			if _, ok := q.TryPop(); ok {
+				return
+			}
+			runtime.Gosched()
+		}
+	})
+}
+
+func BenchmarkChan(b *testing.B) {
+	c := make(chan int, benchQueueCap)
+	benchPair(b, func(v int) { c <- v }, func() { <-c })
+}
+
+func BenchmarkMPMCParallel(b *testing.B) {
+	q := ringbuf.NewMPMC[int](benchQueueCap)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for !q.TryPush(1) {
+				runtime.Gosched()
+			}
+			for {
+				if _, ok := q.TryPop(); ok {
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+	})
+}
+
+func BenchmarkChanParallel(b *testing.B) {
+	c := make(chan int, benchQueueCap)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c <- 1
+			<-c
+		}
+	})
+}
+
+// benchPair runs push on a producer goroutine
+// and pop on the benchmark goroutine b.N times.
+func benchPair(b *testing.B, push func(int), pop func()) {
+
+	b.ResetTimer()
+
+	go func() {
+		for i := range b.N {
+			push(i)
+		}
+	}()
+
+	for range b.N {
+		pop()
+	}
+}
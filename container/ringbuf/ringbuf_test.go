@@ -4,12 +4,15 @@
 package ringbuf_test
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"runtime"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -18,7 +21,7 @@ import (
 
 type testCase struct {
 	line      int
-	ops       func(b *ringbuf.ByteBuffer) (read []byte)
+	ops       func(t *testing.T, b *ringbuf.ByteBuffer) (read []byte)
 	wantRead  string
 	wantBytes string
 }
@@ -27,13 +30,13 @@ func TestRingBuf(t *testing.T) {
 	runTests(t, 15, nil, []testCase{
 		{
 			line(),
-			func(b *ringbuf.ByteBuffer) []byte { return nil },
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte { return nil },
 			"",
 			"",
 		},
 		{
 			line(),
-			func(b *ringbuf.ByteBuffer) []byte {
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
 				b.Write([]byte("hello "))
 				b.WriteString("world")
 				return nil
@@ -43,7 +46,7 @@ func TestRingBuf(t *testing.T) {
 		},
 		{
 			line(),
-			func(b *ringbuf.ByteBuffer) []byte {
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
 				b.Write([]byte(" ab"))
 				b.WriteString("cxyz")
 				return nil
@@ -54,6 +57,299 @@ func TestRingBuf(t *testing.T) {
 	})
 }
 
+func TestTruncate(t *testing.T) {
+	runTests(t, 10, nil, []testCase{
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.WriteString("hello world")
+				return b.Next(2)
+			},
+			"el",
+			"lo world",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.Truncate(5)
+				return nil
+			},
+			"",
+			"lo wo",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.WriteString("rldwide")
+				return b.Next(3)
+			},
+			" wo",
+			"rldwide",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.Truncate(0)
+				b.WriteString("ly")
+				return nil
+			},
+			"",
+			"ly",
+		},
+	})
+}
+
+func TestSetMaxLen(t *testing.T) {
+	runTests(t, 10, nil, []testCase{
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.WriteString("0123456789abc")
+				return b.Next(1)
+			},
+			"3",
+			"456789abc",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.SetMaxLen(6, ringbuf.DiscardOldest)
+				return nil
+			},
+			"",
+			"789abc",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.WriteString("de")
+				b.SetMaxLen(4, ringbuf.DiscardNewest)
+				return nil
+			},
+			"",
+			"9abc",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.SetMaxLen(8, ringbuf.DiscardOldest)
+				b.WriteString("xyz")
+				return b.Next(2)
+			},
+			"9a",
+			"bcxyz",
+		},
+	})
+}
+
+func TestSeek(t *testing.T) {
+	runTests(t, 8, nil, []testCase{
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.WriteString("0123456789")
+				b.Seek(3, io.SeekStart)
+				return nil
+			},
+			"",
+			"56789",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.Seek(-2, io.SeekCurrent)
+				return b.Next(1)
+			},
+			"3",
+			"456789",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				b.Seek(-3, io.SeekEnd)
+				b.WriteString("ab")
+				return nil
+			},
+			"",
+			"789ab",
+		},
+		{
+			line(),
+			func(t *testing.T, b *ringbuf.ByteBuffer) []byte {
+				if _, err := b.Seek(-1, io.SeekStart); err == nil {
+					t.Fatal("seeking before the start succeeded")
+				}
+				b.Seek(100, io.SeekCurrent)
+				return nil
+			},
+			"",
+			"",
+		},
+	})
+}
+
+func TestDropAccounting(t *testing.T) {
+
+	b := ringbuf.NewBuffer[byte](5)
+	b.ReportOverruns(true)
+
+	var dropped []byte
+	b.OnOverwrite(func(s []byte) {
+		dropped = append(dropped, s...)
+	})
+
+	b.Write([]byte("abc"))
+	b.Next(1)
+	b.Write([]byte("defg"))
+
+	// "a" was read, so only "b" was dropped.
+	diff(t, "dropped", "b", dropped)
+	assertEqual(t, "b.Written", 7, b.Written())
+	assertEqual(t, "b.Dropped", 1, b.Dropped())
+
+	b.Write([]byte("0123456789"))
+	diff(t, "dropped", "bcdefg01234", dropped)
+	assertEqual(t, "b.Dropped", 11, b.Dropped())
+
+	_, err := b.Read(make([]byte, 5))
+	var overrun *ringbuf.OverrunError
+	if !errors.As(err, &overrun) || !errors.Is(err, ringbuf.ErrOverrun) {
+		t.Fatalf("b.Read: want *OverrunError, got %v", err)
+	}
+	assertEqual(t, "OverrunError.Dropped", 11, overrun.Dropped)
+
+	got := make([]byte, 5)
+	n, err := b.Read(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff(t, "b.Read", "56789", got[:n])
+}
+
+func TestNextOverrun(t *testing.T) {
+
+	b := ringbuf.NewBuffer[byte](4)
+	b.ReportOverruns(true)
+	b.Write([]byte("abcdef"))
+
+	// Next reads despite the pending overrun,
+	// which is still reported by Read.
+	diff(t, "b.Next", "cd", b.Next(2))
+
+	_, err := b.Read(make([]byte, 4))
+	if !errors.Is(err, ringbuf.ErrOverrun) {
+		t.Fatalf("b.Read: want *OverrunError, got %v", err)
+	}
+
+	n, _ := b.Read(make([]byte, 4))
+	assertEqual(t, "b.Read", 2, n)
+}
+
+func TestReadFromDropAccounting(t *testing.T) {
+
+	b := ringbuf.NewByteBuffer(4)
+
+	var dropped []byte
+	b.OnOverwrite(func(s []byte) {
+		dropped = append(dropped, s...)
+	})
+
+	b.ReadFrom(strings.NewReader("hello world"))
+	diff(t, "dropped", "hello w", dropped)
+	diff(t, "b.Bytes", "orld", b.Bytes())
+	assertEqual(t, "b.Written", 11, b.Written())
+	assertEqual(t, "b.Dropped", 7, b.Dropped())
+}
+
+// wrappedBuffer returns a buffer whose unread data is s,
+// wrapping around the buffer after its first three bytes.
+func wrappedBuffer(s string) *ringbuf.ByteBuffer {
+	b := ringbuf.NewByteBuffer(len(s))
+	b.WriteString(strings.Repeat(" ", len(s)-3) + s[:3])
+	b.Discard(len(s) - 3)
+	b.WriteString(s[3:])
+	return b
+}
+
+func TestSearch(t *testing.T) {
+
+	b := wrappedBuffer("fgh\r\nxy\n")
+
+	assertEqual(t, "b.IndexByte", 4, b.IndexByte('\n'))
+	assertEqual(t, "b.IndexByte", -1, b.IndexByte('z'))
+	assertEqual(t, "b.Index", 2, b.Index([]byte("h\r\nx")))
+	assertEqual(t, "b.Index", 6, b.Index([]byte("y\n")))
+	assertEqual(t, "b.Index", -1, b.Index([]byte("hx")))
+	assertEqual(t, "b.HasPrefix", true, b.HasPrefix([]byte("fgh\r\n")))
+	assertEqual(t, "b.HasPrefix", false, b.HasPrefix([]byte("fgx")))
+	assertEqual(t, "b.HasPrefix", false, b.HasPrefix([]byte("fgh\r\nxy\n!")))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		b.Index([]byte("h\r\nx"))
+	})
+	assertEqual(t, "allocations of b.Index", 0, allocs)
+}
+
+func TestPeek(t *testing.T) {
+
+	b := wrappedBuffer("0123456789")
+
+	got, err := b.Peek(5)
+	diff(t, "b.Peek", "01234", got)
+	assertEqual(t, "b.Peek error", nil, err)
+
+	n, _ := b.Discard(2)
+	assertEqual(t, "b.Discard", 2, n)
+
+	got, err = b.Peek(20)
+	diff(t, "b.Peek", "23456789", got)
+	assertEqual(t, "b.Peek error", io.EOF, err)
+
+	n, err = b.Discard(20)
+	assertEqual(t, "b.Discard", 8, n)
+	assertEqual(t, "b.Discard error", io.EOF, err)
+}
+
+func TestReadLine(t *testing.T) {
+
+	b := wrappedBuffer("fgh\r\nxy\nz")
+
+	for _, want := range []string{"fgh", "xy"} {
+		line, isPrefix, err := b.ReadLine()
+		diff(t, "b.ReadLine", want, line)
+		assertEqual(t, "b.ReadLine isPrefix", false, isPrefix)
+		assertEqual(t, "b.ReadLine error", nil, err)
+	}
+
+	// An incomplete line is left unread unless the buffer is full.
+	_, _, err := b.ReadLine()
+	assertEqual(t, "b.ReadLine error", io.EOF, err)
+	assertEqual(t, "b.Len", 1, b.Len())
+
+	b = wrappedBuffer("abcdef")
+	line, err := b.ReadSlice('\n')
+	diff(t, "b.ReadSlice", "abcdef", line)
+	assertEqual(t, "b.ReadSlice error", bufio.ErrBufferFull, err)
+}
+
+func TestScan(t *testing.T) {
+
+	b := wrappedBuffer("one two thr")
+
+	var got []string
+	for token, err := range b.Scan(bufio.ScanWords) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(token))
+	}
+
+	if diff := cmp.Diff([]string{"one", "two"}, got); diff != "" {
+		t.Errorf("b.Scan: incorrect result (-want +got):\n%s", diff)
+	}
+	diff(t, "b.Bytes", "thr", b.Bytes())
+}
+
 func runTests(t *testing.T, maxLen int, initialCap *int, testCases []testCase) {
 
 	t.Helper()
@@ -68,7 +364,7 @@ func runTests(t *testing.T, maxLen int, initialCap *int, testCases []testCase) {
 	for i, tt := range testCases {
 		t.Run(fmt.Sprintf("test%d-line%d", i, tt.line), func(t *testing.T) {
 
-			gotRead := tt.ops(b)
+			gotRead := tt.ops(t, b)
 			diff(t, "wantRead, gotRead", tt.wantRead, gotRead)
 
 			assertEqual(t, "b.Len", b.Len(), len(tt.wantBytes))
@@ -82,7 +378,9 @@ func runTests(t *testing.T, maxLen int, initialCap *int, testCases []testCase) {
 			gotString := b.String()
 			diff(t, "b.String", tt.wantBytes, gotString)
 
-			gotReadAt := readReaderAt(b)
+			// ReadAt offsets include the elements that were already read.
+			off, _ := b.Seek(0, io.SeekCurrent)
+			gotReadAt := readReaderAt(b, off)
 			diff(t, "b.ReadAt", tt.wantBytes, gotReadAt)
 		})
 	}
@@ -117,13 +415,13 @@ func readWriterTo(w io.WriterTo) []byte {
 	return buf.Bytes()
 }
 
-func readReaderAt(r io.ReaderAt) (b []byte) {
+func readReaderAt(r io.ReaderAt, start int64) (b []byte) {
 	off := 0
 	for {
 		if off == cap(b) {
 			b = slices.Grow(b, 128)
 		}
-		n, err := r.ReadAt(b[off:cap(b)], int64(off))
+		n, err := r.ReadAt(b[off:cap(b)], start+int64(off))
 		off += n
 		b = b[:off]
 		if err == io.EOF {
@@ -0,0 +1,188 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"strings"
+)
+
+// TailBuffer keeps the tail of a stream of text
+// made of whole lines, such as the output of a process.
+type TailBuffer struct {
+	b        *ByteBuffer
+	maxLines int
+	newlines int  // newlines is the number of line feeds in the unread data of b.
+	omitted  int  // omitted is the number of lines discarded.
+	cut      bool // cut is whether the unread data of b starts in the middle of a line.
+}
+
+// NewTailBuffer returns a new tail buffer keeping
+// at most the last maxLines lines and maxBytes bytes of the stream,
+// discarding lines as a whole.
+// A zero limit means no limit.
+//
+// The last line counts toward the limits even if it's not terminated.
+// A single line longer than maxBytes is discarded.
+func NewTailBuffer(maxLines, maxBytes int) *TailBuffer {
+
+	if maxLines < 0 || maxBytes < 0 {
+		panic("ringbuf.NewTailBuffer: negative limit")
+	}
+	if maxBytes == 0 {
+		maxBytes = math.MaxInt
+	}
+
+	t := &TailBuffer{
+		b:        NewByteBuffer(maxBytes),
+		maxLines: maxLines,
+	}
+
+	t.b.OnOverwrite(func(dropped []byte) {
+		n := bytes.Count(dropped, []byte{'\n'})
+		t.newlines -= n
+		t.omitted += n
+		t.cut = dropped[len(dropped)-1] != '\n'
+	})
+
+	return t
+}
+
+func (t *TailBuffer) Write(p []byte) (int, error) {
+
+	t.newlines += bytes.Count(p, []byte{'\n'})
+	t.b.Write(p)
+
+	// Drop what's left of a line whose start was overwritten,
+	// once it has been terminated.
+	if t.cut && t.newlines > 0 {
+		t.discardLines(1)
+		t.cut = false
+	}
+
+	if t.maxLines > 0 {
+		if n := t.lines() - t.maxLines; n > 0 {
+			t.discardLines(n)
+		}
+	}
+
+	return len(p), nil
+}
+
+func (t *TailBuffer) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
+
+// lines returns the number of lines in the buffer.
+func (t *TailBuffer) lines() int {
+	n := t.newlines
+	if t.b.Len() > 0 && !t.endsWithNewline() {
+		n++
+	}
+	return n
+}
+
+func (t *TailBuffer) endsWithNewline() bool {
+	var last byte
+	for s := range t.b.BytesSeq() {
+		last = s[len(s)-1]
+	}
+	return last == '\n'
+}
+
+// discardLines discards the n oldest lines of the buffer,
+// which must have at least n line feeds.
+func (t *TailBuffer) discardLines(n int) {
+	skip := 0
+	k := n
+	for s := range t.b.BytesSeq() {
+		for k > 0 {
+			i := bytes.IndexByte(s, '\n')
+			if i == -1 {
+				skip += len(s)
+				break
+			}
+			skip += i + 1
+			s = s[i+1:]
+			k--
+		}
+		if k == 0 {
+			break
+		}
+	}
+	t.b.Seek(int64(skip), io.SeekCurrent)
+	t.newlines -= n
+	t.omitted += n
+
+	// Release the discarded lines once they take up
+	// as much storage as the kept ones,
+	// so the buffer doesn't grow with the stream.
+	if t.b.readPos > 0 && t.b.readPos >= t.b.Len() {
+		t.b.compact()
+	}
+}
+
+// Len returns the number of bytes of the lines kept in the buffer.
+func (t *TailBuffer) Len() int {
+	return t.b.Len()
+}
+
+// Cap returns the capacity of the storage of the buffer,
+// which is proportional to the size of the kept lines
+// rather than to the size of the stream.
+func (t *TailBuffer) Cap() int {
+	return t.b.Cap()
+}
+
+// Omitted returns the number of lines discarded from the buffer,
+// including a line that was partly discarded.
+func (t *TailBuffer) Omitted() int {
+	if t.cut {
+		return t.omitted + 1
+	}
+	return t.omitted
+}
+
+// Lines returns an iterator over the lines of the buffer,
+// without their line feeds.
+func (t *TailBuffer) Lines() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		if t.cut {
+			return
+		}
+		for line := range bytes.Lines(t.b.Bytes()) {
+			if !yield(bytes.TrimSuffix(line, []byte{'\n'})) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the content of the buffer,
+// preceded by a "... N lines omitted" line if lines were discarded.
+func (t *TailBuffer) String() string {
+	var sb strings.Builder
+	switch n := t.Omitted(); n {
+	case 0:
+	case 1:
+		sb.WriteString("... 1 line omitted\n")
+	default:
+		fmt.Fprintf(&sb, "... %d lines omitted\n", n)
+	}
+	if !t.cut {
+		sb.WriteString(t.b.String())
+	}
+	return sb.String()
+}
+
+// WriteTo writes the content of the buffer to w like [TailBuffer.String].
+// The buffer is left unchanged.
+func (t *TailBuffer) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, t.String())
+	return int64(n), err
+}
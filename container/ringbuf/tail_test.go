@@ -0,0 +1,116 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestTailBuffer(t *testing.T) {
+
+	tests := []struct {
+		line     int
+		maxLines int
+		maxBytes int
+		writes   []string
+		want     string
+	}{
+		{
+			line(), 3, 0,
+			[]string{"a\nb\n", "c\nd", "\ne\n"},
+			"... 2 lines omitted\nc\nd\ne\n",
+		},
+		{
+			line(), 2, 0,
+			[]string{"a\nb\nc"},
+			"... 1 line omitted\nb\nc",
+		},
+		{
+			line(), 0, 8,
+			[]string{"first\nsecond\nthird\n"},
+			"... 2 lines omitted\nthird\n",
+		},
+		{
+			line(), 0, 8,
+			[]string{"one\ntwo\n", "three\n"},
+			"... 2 lines omitted\nthree\n",
+		},
+		{
+			line(), 0, 4,
+			[]string{"a very long line"},
+			"... 1 line omitted\n",
+		},
+		{
+			line(), 0, 4,
+			[]string{"a very long line\nok"},
+			"... 1 line omitted\nok",
+		},
+		{
+			line(), 2, 100,
+			[]string{"x\n", "y\n", "z\n"},
+			"... 1 line omitted\ny\nz\n",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test%d-line%d", i, tt.line), func(t *testing.T) {
+
+			b := ringbuf.NewTailBuffer(tt.maxLines, tt.maxBytes)
+			for _, s := range tt.writes {
+				b.WriteString(s)
+			}
+			diff(t, "b.String", tt.want, b.String())
+
+			var lines []string
+			for line := range b.Lines() {
+				lines = append(lines, string(line))
+			}
+			_, body, _ := strings.Cut(tt.want, "omitted\n")
+			if !strings.Contains(tt.want, "omitted\n") {
+				body = tt.want
+			}
+			wantLines := slices.Collect(strings.Lines(body))
+			for i := range wantLines {
+				wantLines[i] = strings.TrimSuffix(wantLines[i], "\n")
+			}
+			if !slices.Equal(lines, wantLines) {
+				t.Errorf("b.Lines: want %q, got %q", wantLines, lines)
+			}
+		})
+	}
+}
+
+func TestTailBufferBounded(t *testing.T) {
+
+	line := strings.Repeat("x", 100) + "\n"
+
+	for _, maxBytes := range []int{0, 1000} {
+
+		b := ringbuf.NewTailBuffer(3, maxBytes)
+		maxCap := 0
+
+		for i := range 100000 {
+			b.WriteString(line)
+			if i%7 == 0 {
+				b.WriteString(line + line)
+			}
+			maxCap = max(maxCap, b.Cap())
+		}
+
+		if b.Len() != 3*len(line) {
+			t.Errorf("maxBytes %d: Len = %d, want %d", maxBytes, b.Len(), 3*len(line))
+		}
+		if maxCap > 16*len(line) {
+			t.Errorf("maxBytes %d: Cap reached %d, want at most %d", maxBytes, maxCap, 16*len(line))
+		}
+		if want := "... 128569 lines omitted\n" + strings.Repeat(line, 3); b.String() != want {
+			t.Errorf("maxBytes %d: String = %q", maxBytes, b.String())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf
+
+import (
+	"io"
+	"iter"
+	"sort"
+	"time"
+)
+
+// TimeBuffer is a ring buffer of timestamped elements,
+// keeping those added within a max age, up to a max length,
+// for tracking things like the events of the last 5 minutes.
+type TimeBuffer[T any] struct {
+	vals   *Buffer[T]
+	times  *Buffer[time.Time] // times holds the timestamps of vals, with the same layout.
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// NewTimeBuffer returns a new time buffer keeping the elements
+// added at most maxAge ago, and at most the last maxLen of them.
+// A zero maxAge means no age limit.
+//
+// Timestamps are taken from now, which is [time.Now] if not provided.
+func NewTimeBuffer[T any](maxLen int, maxAge time.Duration, now ...func() time.Time) *TimeBuffer[T] {
+
+	if maxAge < 0 {
+		panic("ringbuf.NewTimeBuffer: maxAge < 0")
+	}
+
+	b := &TimeBuffer[T]{
+		vals:   NewBuffer[T](maxLen),
+		times:  NewBuffer[time.Time](maxLen),
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+	if len(now) > 0 && now[0] != nil {
+		b.now = now[0]
+	}
+
+	return b
+}
+
+// Add adds v to the buffer, timestamped with the current time.
+func (b *TimeBuffer[T]) Add(v T) {
+	t := b.now()
+	b.vals.WriteByte(v)
+	b.times.WriteByte(t)
+	b.evict(t)
+}
+
+// evict discards the elements older than maxAge at time now.
+// Timestamps are assumed not to decrease.
+func (b *TimeBuffer[T]) evict(now time.Time) {
+	if b.maxAge == 0 {
+		return
+	}
+	cutoff := now.Add(-b.maxAge)
+	n := sort.Search(b.times.Len(), func(i int) bool {
+		return !b.time(i).Before(cutoff)
+	})
+	b.vals.Seek(int64(n), io.SeekCurrent)
+	b.times.Seek(int64(n), io.SeekCurrent)
+}
+
+// time returns the timestamp of the i-th retained element.
+func (b *TimeBuffer[T]) time(i int) time.Time {
+	return b.times.buf[b.index(i)]
+}
+
+// index returns the position in the buffers of the i-th retained element.
+func (b *TimeBuffer[T]) index(i int) int {
+	return (b.times.writePos + b.times.readPos + i) % len(b.times.buf)
+}
+
+// All returns an iterator over the timestamps and values
+// of the elements of the buffer, from oldest to newest.
+func (b *TimeBuffer[T]) All() iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		b.evict(b.now())
+		b.from(0)(yield)
+	}
+}
+
+// Since returns an iterator over the timestamps and values
+// of the elements of the buffer added after t, from oldest to newest.
+func (b *TimeBuffer[T]) Since(t time.Time) iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		b.evict(b.now())
+		i := sort.Search(b.times.Len(), func(i int) bool {
+			return b.time(i).After(t)
+		})
+		b.from(i)(yield)
+	}
+}
+
+// from returns an iterator over the elements of the buffer
+// starting at the i-th one.
+func (b *TimeBuffer[T]) from(i int) iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		for ; i < b.times.Len(); i++ {
+			j := b.index(i)
+			if !yield(b.times.buf[j], b.vals.buf[j]) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of elements of the buffer
+// that haven't expired.
+func (b *TimeBuffer[T]) Len() int {
+	b.evict(b.now())
+	return b.times.Len()
+}
+
+func (b *TimeBuffer[T]) MaxLen() int {
+	return b.times.MaxLen()
+}
+
+func (b *TimeBuffer[T]) MaxAge() time.Duration {
+	return b.maxAge
+}
+
+func (b *TimeBuffer[T]) Reset() {
+	b.vals.Reset()
+	b.times.Reset()
+}
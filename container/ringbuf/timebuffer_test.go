@@ -0,0 +1,58 @@
+// Copyright 2025 the toolbox authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ringbuf_test
+
+import (
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/layer8co/toolbox/container/ringbuf"
+)
+
+func TestTimeBuffer(t *testing.T) {
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	clock := func() time.Time { return now }
+
+	b := ringbuf.NewTimeBuffer[int](4, 5*time.Minute, clock)
+
+	for i := range 5 {
+		b.Add(i)
+		now = now.Add(time.Minute)
+	}
+
+	// The oldest element is evicted by length.
+	assertEqual(t, "b.Len", 4, b.Len())
+	assertTimeBuffer(t, "b.All", b.All(), []int{1, 2, 3, 4})
+
+	// Elements are evicted by age as time passes.
+	now = start.Add(7 * time.Minute)
+	assertTimeBuffer(t, "b.All", b.All(), []int{2, 3, 4})
+
+	assertTimeBuffer(t, "b.Since", b.Since(start.Add(3*time.Minute)), []int{4})
+	assertTimeBuffer(t, "b.Since", b.Since(start), []int{2, 3, 4})
+
+	now = start.Add(10 * time.Minute)
+	assertEqual(t, "b.Len", 0, b.Len())
+
+	b.Add(5)
+	assertTimeBuffer(t, "b.All", b.All(), []int{5})
+	for ts := range b.All() {
+		assertEqual(t, "timestamp", now, ts)
+	}
+}
+
+func assertTimeBuffer(t *testing.T, title string, seq iter.Seq2[time.Time, int], want []int) {
+	t.Helper()
+	got := []int{}
+	for _, v := range seq {
+		got = append(got, v)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("%s: incorrect result (-want +got):\n%s", title, diff)
+	}
+}